@@ -0,0 +1,260 @@
+// Package glox embeds the Lox interpreter in Go programs.
+//
+//	vm := glox.NewVM(glox.Options{})
+//	if _, err := vm.Eval(ctx, `fun add(a, b) { return a + b; }`); err != nil {
+//		...
+//	}
+//	sum, err := vm.Call("add", 1, 2)
+package glox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dmcg310/glox/src/lox"
+)
+
+type Options struct {
+	// SearchPath lists the directories imports are looked up in after the
+	// directory of the importing file.
+	SearchPath []string
+
+	// Stdout is where print writes, standard output if it isn't set.
+	Stdout io.Writer
+
+	// Stderr receives warnings about scripts, which are dropped if it isn't
+	// set. Errors are returned rather than written.
+	Stderr io.Writer
+
+	// Timeout bounds how long each Eval or Call may run for. Together with
+	// the limits below, a zero value means no limit, and a script that goes
+	// past one stops with a *LimitError.
+	Timeout time.Duration
+
+	// MaxSteps caps the statements executed, loop iterations started and
+	// calls made by each Eval or Call.
+	MaxSteps int64
+
+	// MaxCallDepth is the exception: zero means a default depth, because
+	// recursing without a limit would crash the process instead of
+	// failing the script.
+	MaxCallDepth      int
+	MaxLoopIterations int64
+}
+
+// VM is an interpreter whose globals persist from one Eval to the next. A
+// VM is not safe for concurrent use.
+type VM struct {
+	lox      *lox.Lox
+	reporter *collector
+	timeout  time.Duration
+}
+
+func NewVM(opts Options) *VM {
+	reporter := &collector{warnings: opts.Stderr}
+	interpreter := lox.NewInterpreter()
+	interpreter.Limits.MaxSteps = opts.MaxSteps
+	interpreter.Limits.MaxLoopIterations = opts.MaxLoopIterations
+	if opts.MaxCallDepth > 0 {
+		interpreter.Limits.MaxCallDepth = opts.MaxCallDepth
+	}
+
+	return &VM{
+		lox: &lox.Lox{
+			Interpreter: interpreter,
+			Reporter:    reporter,
+			SearchPath:  opts.SearchPath,
+			Stdout:      opts.Stdout,
+		},
+		reporter: reporter,
+		timeout:  opts.Timeout,
+	}
+}
+
+// Eval runs source and returns the value of its last statement if that was
+// an expression statement, or nil otherwise. Unlike the glox command, Eval
+// doesn't print the values of expression statements.
+func (vm *VM) Eval(ctx context.Context, source string) (Value, error) {
+	if err := ctx.Err(); err != nil {
+		return Value{}, cancelled(err)
+	}
+
+	vm.lox.HadError = false
+	vm.reporter.errors = nil
+
+	ctx, cancel := vm.withTimeout(ctx)
+	defer cancel()
+
+	result, err := vm.lox.EvalContext(ctx, source)
+	if err != nil {
+		return Value{}, vm.convertError(err)
+	}
+
+	return Value{raw: result}, nil
+}
+
+// Call calls the global function or class name with args, each of which is
+// converted with ValueOf.
+func (vm *VM) Call(name string, args ...interface{}) (Value, error) {
+	return vm.CallContext(context.Background(), name, args...)
+}
+
+// CallContext is Call for a call that stops once ctx is done.
+func (vm *VM) CallContext(ctx context.Context, name string, args ...interface{}) (Value, error) {
+	if err := ctx.Err(); err != nil {
+		return Value{}, cancelled(err)
+	}
+
+	callee, ok := vm.lox.Global(name)
+	if !ok {
+		return Value{}, fmt.Errorf("glox: undefined global '%s'", name)
+	}
+
+	arguments := make([]interface{}, len(args))
+	for idx, arg := range args {
+		value, err := ValueOf(arg)
+		if err != nil {
+			return Value{}, err
+		}
+
+		arguments[idx] = value.raw
+	}
+
+	ctx, cancel := vm.withTimeout(ctx)
+	defer cancel()
+
+	result, err := vm.lox.CallContext(ctx, callee, arguments)
+	if err != nil {
+		return Value{}, vm.convertError(err)
+	}
+
+	return Value{raw: result}, nil
+}
+
+// SetGlobal defines a global variable, converting value with ValueOf.
+func (vm *VM) SetGlobal(name string, value interface{}) error {
+	converted, err := ValueOf(value)
+	if err != nil {
+		return err
+	}
+
+	vm.lox.SetGlobal(name, converted.raw)
+
+	return nil
+}
+
+func (vm *VM) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if vm.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, vm.timeout)
+}
+
+func (vm *VM) GetGlobal(name string) (Value, bool) {
+	value, ok := vm.lox.Global(name)
+
+	return Value{raw: value}, ok
+}
+
+// CompileError holds every error reported while scanning, parsing and
+// resolving a script, none of which was run.
+type CompileError struct {
+	Errors []string
+}
+
+func (e *CompileError) Error() string {
+	return strings.Join(e.Errors, "\n")
+}
+
+// RuntimeError is a runtime error, or an exception thrown and not caught,
+// in a script. Value is the thrown value, and nil for a runtime error.
+type RuntimeError struct {
+	Message string
+	Line    int
+	Value   Value
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("[line %d] %s", e.Line, e.Message)
+}
+
+type Limit int
+
+const (
+	StepLimit Limit = iota
+	CallDepthLimit
+	LoopLimit
+	// Cancelled means the context was cancelled or its deadline, or the
+	// VM's Timeout, passed.
+	Cancelled
+)
+
+// LimitError stops a script that went past one of the VM's limits. For
+// Cancelled, Err is the context's error, so errors.Is(err,
+// context.DeadlineExceeded) reports a timeout.
+type LimitError struct {
+	Limit   Limit
+	Message string
+	Line    int
+	Err     error
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("[line %d] %s", e.Line, e.Message)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// cancelled is the LimitError for a context that was done before the script
+// started, the same as if it had been cancelled while running.
+func cancelled(err error) error {
+	limitErr := &lox.LimitError{Limit: lox.CANCELLED, Err: err}
+
+	return &LimitError{Limit: Cancelled, Message: limitErr.Error(), Err: err}
+}
+
+func (vm *VM) convertError(err error) error {
+	if errors.Is(err, lox.ErrCompile) {
+		return &CompileError{Errors: vm.reporter.errors}
+	}
+
+	switch e := err.(type) {
+	case *lox.RuntimeError:
+		return &RuntimeError{Message: e.Msg, Line: e.Token.Line}
+	case *lox.LimitError:
+		return &LimitError{Limit: Limit(e.Limit), Message: e.Error(), Line: e.Token.Line, Err: e.Err}
+	case *lox.Throw:
+		return &RuntimeError{
+			Message: "Uncaught exception: " + lox.Stringify(e.Value),
+			Line:    e.Keyword.Line,
+			Value:   Value{raw: e.Value},
+		}
+	}
+
+	return err
+}
+
+// collector gathers compile errors for Eval to return instead of writing
+// them out. Warnings don't stop a script from running, so they are only
+// written, and only if there is somewhere to write them.
+type collector struct {
+	errors   []string
+	warnings io.Writer
+}
+
+func (c *collector) Error(line int, message string) {
+	c.errors = append(c.errors, fmt.Sprintf("[line %d] Error: %s", line, message))
+}
+
+func (c *collector) Warning(line int, message string) {
+	if c.warnings != nil {
+		fmt.Fprintf(c.warnings, "[line %d] Warning: %s\n", line, message)
+	}
+}
@@ -0,0 +1,315 @@
+package glox
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustEval(t *testing.T, vm *VM, source string) Value {
+	t.Helper()
+
+	value, err := vm.Eval(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Eval(%q): %v", source, err)
+	}
+
+	return value
+}
+
+func TestEvalReturnsLastExpression(t *testing.T) {
+	vm := NewVM(Options{})
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"1 + 2;", "3"},
+		{`"a" + "b";`, "ab"},
+		{"var x = 1;", "nil"},
+		{"1; var y = 2;", "nil"},
+		{"[1, 2, 3];", "[1, 2, 3]"},
+	}
+
+	for _, test := range tests {
+		if got := mustEval(t, vm, test.source).String(); got != test.want {
+			t.Errorf("Eval(%q) = %s, want %s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestEvalKeepsGlobals(t *testing.T) {
+	vm := NewVM(Options{})
+	mustEval(t, vm, "var count = 1;")
+	mustEval(t, vm, "count = count + 1;")
+
+	if got := mustEval(t, vm, "count;"); got.String() != "2" {
+		t.Errorf("count = %s, want 2", got)
+	}
+}
+
+func TestEvalDoesNotEchoExpressions(t *testing.T) {
+	var out strings.Builder
+	vm := NewVM(Options{Stdout: &out})
+	mustEval(t, vm, `1 + 2; print "printed";`)
+
+	if out.String() != "printed\n" {
+		t.Errorf("output = %q, want only the print", out.String())
+	}
+}
+
+func TestEvalWritesWarningsToStderr(t *testing.T) {
+	var errs strings.Builder
+	vm := NewVM(Options{Stderr: &errs})
+	mustEval(t, vm, "match (1) { _ => 1; case 1 => 2; }")
+
+	if !strings.Contains(errs.String(), "Warning: at 'case': Unreachable match arm.") {
+		t.Errorf("stderr = %q, want the warning", errs.String())
+	}
+}
+
+func TestEvalCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewVM(Options{}).Eval(ctx, "1;")
+	limitError(t, err, Cancelled)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	vm := NewVM(Options{})
+	mustEval(t, vm, `
+		fun add(a, b) { return a + b; }
+		class Point { init(x, y) { this.x = x; this.y = y; } }
+	`)
+
+	sum, err := vm.Call("add", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := sum.AsInt(); err != nil || n != 3 {
+		t.Errorf("add(1, 2) = %v, %v, want 3", sum, err)
+	}
+
+	point, err := vm.Call("Point", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if point.Kind() != Instance {
+		t.Errorf("Point(1, 2) is a %s, want an instance", point.Kind())
+	}
+
+	if _, err := vm.Call("missing"); err == nil {
+		t.Error("calling an undefined global succeeded")
+	}
+
+	if _, err := vm.Call("add", 1); err == nil || !strings.Contains(err.Error(), "Expected 2 arguments but got 1.") {
+		t.Errorf("add(1) error = %v, want an arity error", err)
+	}
+
+	var runtimeErr *RuntimeError
+	if _, err := vm.Call("add", 1, "a"); !errors.As(err, &runtimeErr) {
+		t.Errorf("add(1, \"a\") error = %v, want a *RuntimeError", err)
+	}
+}
+
+func TestSetAndGetGlobal(t *testing.T) {
+	vm := NewVM(Options{})
+	if err := vm.SetGlobal("name", "lox"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mustEval(t, vm, `"hello " + name;`); got.String() != "hello lox" {
+		t.Errorf("got %s, want hello lox", got)
+	}
+
+	mustEval(t, vm, "var answer = 42;")
+	answer, ok := vm.GetGlobal("answer")
+	if !ok || answer.String() != "42" {
+		t.Errorf("GetGlobal(answer) = %v, %v, want 42", answer, ok)
+	}
+
+	if _, ok := vm.GetGlobal("clock"); !ok {
+		t.Error("natives aren't visible through GetGlobal")
+	}
+
+	if _, ok := vm.GetGlobal("missing"); ok {
+		t.Error("GetGlobal found an undefined global")
+	}
+
+	if err := vm.SetGlobal("bad", make(chan int)); err == nil {
+		t.Error("SetGlobal accepted a channel")
+	}
+}
+
+func TestValueOf(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	tests := []struct {
+		value interface{}
+		kind  Kind
+		want  string
+	}{
+		{nil, Nil, "nil"},
+		{true, Bool, "true"},
+		{int8(-3), Int, "-3"},
+		{uint32(7), Int, "7"},
+		{uint64(1 << 63), BigInt, "9223372036854775808"},
+		{float32(1.5), Float, "1.5"},
+		{2.25, Float, "2.25"},
+		{huge, BigInt, "123456789012345678901234567890"},
+		{big.NewRat(1, 4), Decimal, "0.25"},
+		{"text", String, "text"},
+		{[]int{1, 2}, List, "[1, 2]"},
+		{[2]string{"a", "b"}, List, `["a", "b"]`},
+		{map[string]int{"b": 2, "a": 1}, Map, `{"a": 1, "b": 2}`},
+	}
+
+	for _, test := range tests {
+		value, err := ValueOf(test.value)
+		if err != nil {
+			t.Errorf("ValueOf(%#v): %v", test.value, err)
+			continue
+		}
+
+		if value.Kind() != test.kind || value.String() != test.want {
+			t.Errorf("ValueOf(%#v) = %s %s, want %s %s", test.value, value.Kind(), value, test.kind, test.want)
+		}
+	}
+
+	if _, err := ValueOf(func() {}); err == nil {
+		t.Error("ValueOf accepted a func")
+	}
+}
+
+func TestValueAccessors(t *testing.T) {
+	vm := NewVM(Options{})
+
+	if n, err := mustEval(t, vm, "4.0;").AsInt(); err != nil || n != 4 {
+		t.Errorf("AsInt(4.0) = %d, %v", n, err)
+	}
+
+	if _, err := mustEval(t, vm, "4.5;").AsInt(); err == nil {
+		t.Error("AsInt accepted 4.5")
+	}
+
+	if n, err := mustEval(t, vm, "2n ** 100n;").AsBigInt(); err != nil || n.BitLen() != 101 {
+		t.Errorf("AsBigInt(2n ** 100n) = %v, %v", n, err)
+	}
+
+	if r, err := mustEval(t, vm, "0.1d;").AsRat(); err != nil || r.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Errorf("AsRat(0.1d) = %v, %v", r, err)
+	}
+
+	if f, err := mustEval(t, vm, "1;").AsFloat(); err != nil || f != 1 {
+		t.Errorf("AsFloat(1) = %v, %v", f, err)
+	}
+
+	if _, err := mustEval(t, vm, "1;").AsString(); err == nil {
+		t.Error("AsString accepted a number")
+	}
+
+	if !mustEval(t, vm, "0;").Truthy() || mustEval(t, vm, "nil;").Truthy() {
+		t.Error("Truthy doesn't follow Lox's rules")
+	}
+
+	entries, err := mustEval(t, vm, `var m = {"a": [1, "b"]}; m;`).AsMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := entries["a"].AsList()
+	if err != nil || len(list) != 2 || list[1].String() != "b" {
+		t.Errorf("AsList = %v, %v", list, err)
+	}
+
+	want := map[interface{}]interface{}{int64(1): []interface{}{"x", nil}}
+	if got := mustEval(t, vm, `var n = {1: ["x", nil]}; n;`).Interface(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Interface() = %#v, want %#v", got, want)
+	}
+}
+
+func TestInterfaceUnhashableKeys(t *testing.T) {
+	vm := NewVM(Options{})
+
+	entries, ok := mustEval(t, vm, `var k = {[1]: 2, {}: 3}; k;`).Interface().(map[interface{}]interface{})
+	if !ok || len(entries) != 2 {
+		t.Fatalf("Interface() = %#v, want a map of two entries", entries)
+	}
+
+	for key, value := range entries {
+		if _, ok := key.(Value); !ok {
+			t.Errorf("key %#v for %v, want it kept as a Value", key, value)
+		}
+	}
+}
+
+func TestInterfaceCycles(t *testing.T) {
+	vm := NewVM(Options{})
+
+	list, ok := mustEval(t, vm, `var xs = [1]; push(xs, xs); xs;`).Interface().([]interface{})
+	if !ok || len(list) != 2 {
+		t.Fatalf("Interface() = %#v, want a list of two elements", list)
+	}
+
+	if inner, ok := list[1].([]interface{}); !ok || &inner[0] != &list[0] {
+		t.Errorf("list[1] = %#v, want the list itself", list[1])
+	}
+
+	m, ok := mustEval(t, vm, `var m = {}; m["self"] = m; m;`).Interface().(map[interface{}]interface{})
+	if !ok {
+		t.Fatalf("Interface() = %#v, want a map", m)
+	}
+
+	if inner, ok := m["self"].(map[interface{}]interface{}); !ok || reflect.ValueOf(inner).Pointer() != reflect.ValueOf(m).Pointer() {
+		t.Errorf(`m["self"] = %#v, want the map itself`, m["self"])
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	vm := NewVM(Options{})
+
+	_, err := vm.Eval(context.Background(), "var;\nprint (;")
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("err = %v, want a *CompileError", err)
+	}
+
+	if len(compileErr.Errors) != 2 {
+		t.Errorf("Errors = %q, want two", compileErr.Errors)
+	}
+
+	// errors from one Eval don't carry over to the next
+	mustEval(t, vm, "1;")
+}
+
+func TestRuntimeError(t *testing.T) {
+	vm := NewVM(Options{})
+
+	_, err := vm.Eval(context.Background(), "var a = 1;\na + nil;")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("err = %v, want a *RuntimeError", err)
+	}
+
+	if runtimeErr.Line != 2 || !runtimeErr.Value.IsNil() {
+		t.Errorf("got %+v, want line 2 and no value", runtimeErr)
+	}
+
+	_, err = vm.Eval(context.Background(), `throw "oops";`)
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("err = %v, want a *RuntimeError", err)
+	}
+
+	if runtimeErr.Value.String() != "oops" || !strings.Contains(runtimeErr.Message, "Uncaught exception") {
+		t.Errorf("got %+v, want the thrown value", runtimeErr)
+	}
+}
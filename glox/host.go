@@ -0,0 +1,209 @@
+package glox
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// HostOptions limits what scripts can reach of a host object. A nil list
+// exposes every exported field or method, and any other list exposes only
+// the names in it. The lists name members of the wrapped type, so they also
+// limit other values of that type reached through the object, such as a
+// method returning its receiver, but not values of other types.
+type HostOptions struct {
+	Fields  []string
+	Methods []string
+
+	// ReadOnly stops scripts assigning to fields, of the object and of
+	// everything reached through it.
+	ReadOnly bool
+
+	// listed is the type Fields and Methods name members of.
+	listed reflect.Type
+}
+
+// NewHost wraps a Go value so that scripts can read and assign its exported
+// fields with obj.Field and call its exported methods with obj.Method(args),
+// with values converted as for Register. A value that isn't a pointer is
+// copied, and the script works on the copy. A nil pointer becomes nil, as
+// it has no fields to read or methods that are safe to call.
+func NewHost(value interface{}, opts HostOptions) Value {
+	if value == nil {
+		return Value{}
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() == reflect.Pointer && reflected.IsNil() {
+		return Value{}
+	}
+
+	return Value{raw: hostOf(reflected, opts)}
+}
+
+type hostObject struct {
+	value   reflect.Value
+	copied  bool
+	options HostOptions
+}
+
+func hostOf(value reflect.Value, opts HostOptions) *hostObject {
+	copied := false
+	if value.Kind() != reflect.Pointer {
+		// keep the copy addressable so that its fields can be set and
+		// methods with pointer receivers called
+		pointer := reflect.New(value.Type())
+		pointer.Elem().Set(value)
+		value, copied = pointer, true
+	}
+
+	if opts.listed == nil {
+		opts.listed = value.Type()
+	}
+
+	return &hostObject{value: value, copied: copied, options: opts}
+}
+
+func (h *hostObject) GetProperty(name string) (interface{}, error) {
+	fields, methods := h.allowLists()
+	if field, ok := h.field(name, fields); ok {
+		return h.fieldValue(field)
+	}
+
+	if allowed(methods, name) {
+		if method := h.value.MethodByName(name); method.IsValid() {
+			// what a method returns is limited like this object, or a
+			// method returning its receiver would get around the options
+			native, err := nativeFunction(name, method, h.options)
+			if err != nil {
+				return nil, fmt.Errorf("Method '%s' can't be called from Lox.", name)
+			}
+
+			return native, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (h *hostObject) SetProperty(name string, value interface{}) error {
+	fields, _ := h.allowLists()
+	field, ok := h.field(name, fields)
+	if !ok {
+		return fmt.Errorf("Undefined field '%s'.", name)
+	}
+
+	if h.options.ReadOnly || !field.CanSet() {
+		return fmt.Errorf("Can't assign to field '%s'.", name)
+	}
+
+	converted, err := Value{raw: value}.convert(field.Type())
+	var mismatch *mismatchError
+	if errors.As(err, &mismatch) {
+		return fmt.Errorf("Field '%s' must be %s but got %s.", name, mismatch.expected, mismatch.got)
+	} else if err != nil {
+		return fmt.Errorf("Field '%s': %s.", name, err)
+	}
+
+	field.Set(converted)
+
+	return nil
+}
+
+func (h *hostObject) String() string {
+	if stringer, ok := h.value.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	return "<host " + h.value.Type().Elem().String() + ">"
+}
+
+// Interface returns the wrapped Go value, or the script's copy of it.
+func (h *hostObject) Interface() interface{} {
+	if h.copied {
+		return h.value.Elem().Interface()
+	}
+
+	return h.value.Interface()
+}
+
+// allowLists returns the Fields and Methods lists if they were written for
+// this object's type, and nil lists, exposing everything, if not.
+func (h *hostObject) allowLists() ([]string, []string) {
+	if h.value.Type() != h.options.listed {
+		return nil, nil
+	}
+
+	return h.options.Fields, h.options.Methods
+}
+
+// field finds an exported field, including one promoted from an embedded
+// struct, if the allow-list has it.
+func (h *hostObject) field(name string, allowList []string) (reflect.Value, bool) {
+	if !allowed(allowList, name) || h.value.IsNil() {
+		return reflect.Value{}, false
+	}
+
+	structValue := h.value.Elem()
+	if structValue.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	structField, ok := structValue.Type().FieldByName(name)
+	if !ok || !structField.IsExported() {
+		return reflect.Value{}, false
+	}
+
+	// a promoted field is unreachable through a nil embedded pointer
+	field, err := structValue.FieldByIndexErr(structField.Index)
+	if err != nil {
+		return reflect.Value{}, false
+	}
+
+	return field, true
+}
+
+// fieldValue converts a field for a script. A nested struct is wrapped in
+// place, so that assigning to its fields changes the outer value too. Host
+// objects reached through a field get this one's options, so a script can't
+// get around them by going a level deeper.
+func (h *hostObject) fieldValue(field reflect.Value) (interface{}, error) {
+	if field.Kind() == reflect.Struct && field.CanAddr() {
+		return hostOf(field.Addr(), h.options), nil
+	}
+
+	value, err := valueOf(field.Interface(), h.options)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.raw, nil
+}
+
+// assignable returns the wrapped value, or what it points to, if either can
+// be passed as a Go argument of type target.
+func (h *hostObject) assignable(target reflect.Type) (reflect.Value, bool) {
+	if h.value.Type().AssignableTo(target) {
+		return h.value, true
+	}
+
+	if !h.value.IsNil() && h.value.Elem().Type().AssignableTo(target) {
+		return h.value.Elem(), true
+	}
+
+	return reflect.Value{}, false
+}
+
+func allowed(allowList []string, name string) bool {
+	if allowList == nil {
+		return true
+	}
+
+	for _, allowedName := range allowList {
+		if allowedName == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,245 @@
+package glox
+
+import (
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+func (p *point) Move(dx, dy int) {
+	p.X += dx
+	p.Y += dy
+}
+
+type request struct {
+	Method string
+	Target *point
+	Origin point
+	Tags   []string
+	secret string
+}
+
+func (r *request) Header(name string) string {
+	return name + ": " + r.Method
+}
+
+func (r *request) Self() *request {
+	return r
+}
+
+func (r *request) Clone() *request {
+	clone := *r
+	return &clone
+}
+
+type named struct {
+	Name string
+}
+
+func (n *named) String() string {
+	return "named " + n.Name
+}
+
+func newRequest() *request {
+	return &request{Method: "GET", Target: &point{1, 2}, Tags: []string{"a"}, secret: "hidden"}
+}
+
+func TestHostFieldsAndMethods(t *testing.T) {
+	vm := NewVM(Options{})
+	req := newRequest()
+	if err := vm.SetGlobal("req", NewHost(req, HostOptions{})); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"req.Method;", "GET"},
+		{`req.Header("Accept");`, "Accept: GET"},
+		{"req.Target.X;", "1"},
+		{"req.Tags;", `["a"]`},
+		{"req.Target.Move(1, 1); req.Target.Y;", "3"},
+		{`req.Method = "POST"; req.Clone().Method;`, "POST"},
+		{"req.Origin.X = 5; req.Origin.X;", "5"},
+		{"req.Target.X += 1; req.Target.X;", "3"},
+		{"var {Method} = req; Method;", "POST"},
+	}
+
+	for _, test := range tests {
+		if got := mustEval(t, vm, test.source).String(); got != test.want {
+			t.Errorf("Eval(%q) = %s, want %s", test.source, got, test.want)
+		}
+	}
+
+	// scripts change the Go value itself
+	if req.Method != "POST" || req.Origin.X != 5 || *req.Target != (point{3, 3}) {
+		t.Errorf("request is %+v, want the script's changes", req)
+	}
+
+	for _, source := range []string{"req.secret;", "req.Missing;", "req.Method = 1;", "req.Missing = 1;"} {
+		evalError(t, vm, source)
+	}
+}
+
+func TestHostCopiesValues(t *testing.T) {
+	vm := NewVM(Options{})
+	original := point{1, 2}
+	if err := vm.SetGlobal("p", original); err != nil {
+		t.Fatal(err)
+	}
+
+	mustEval(t, vm, "p.Move(1, 1);")
+	p, _ := vm.GetGlobal("p")
+
+	if p.Interface() != (point{2, 3}) || original != (point{1, 2}) {
+		t.Errorf("got %v and original %v, want the script to work on a copy", p.Interface(), original)
+	}
+}
+
+func TestHostReadOnly(t *testing.T) {
+	vm := NewVM(Options{})
+	req := newRequest()
+	if err := vm.SetGlobal("req", NewHost(req, HostOptions{ReadOnly: true})); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{
+		`req.Method = "POST";`,
+		"req.Origin.X = 99;",
+		"req.Target.X = 99;",
+	} {
+		err := evalError(t, vm, source)
+		if !strings.Contains(err.Error(), "Can't assign to field") {
+			t.Errorf("Eval(%q) error = %v, want a read-only error", source, err)
+		}
+	}
+
+	if req.Method != "GET" || req.Target.X != 1 {
+		t.Errorf("request is %+v, want it unchanged", req)
+	}
+
+	if got := mustEval(t, vm, "req.Method;"); got.String() != "GET" {
+		t.Errorf("req.Method = %s, want GET", got)
+	}
+}
+
+func TestHostAllowLists(t *testing.T) {
+	vm := NewVM(Options{})
+	opts := HostOptions{
+		Fields:  []string{"Method", "Target"},
+		Methods: []string{"Clone"},
+	}
+	if err := vm.SetGlobal("req", NewHost(newRequest(), opts)); err != nil {
+		t.Fatal(err)
+	}
+
+	allowed := map[string]string{
+		"req.Method;":         "GET",
+		"req.Clone().Method;": "GET",
+		// the lists name members of a request, not of a point
+		"req.Target.X;": "1",
+	}
+
+	for source, want := range allowed {
+		if got := mustEval(t, vm, source).String(); got != want {
+			t.Errorf("Eval(%q) = %s, want %s", source, got, want)
+		}
+	}
+
+	for _, source := range []string{
+		"req.Tags;",
+		`req.Header("a");`,
+		"req.Origin;",
+		"req.Clone().Tags;",
+	} {
+		err := evalError(t, vm, source)
+		if !strings.Contains(err.Error(), "Undefined property") {
+			t.Errorf("Eval(%q) error = %v, want an undefined property", source, err)
+		}
+	}
+}
+
+func TestHostMethodResults(t *testing.T) {
+	vm := NewVM(Options{})
+	req := newRequest()
+	opts := HostOptions{
+		Fields:   []string{"Method"},
+		Methods:  []string{"Self"},
+		ReadOnly: true,
+	}
+	if err := vm.SetGlobal("req", NewHost(req, opts)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mustEval(t, vm, "req.Self().Method;"); got.String() != "GET" {
+		t.Errorf("req.Self().Method = %s, want GET", got)
+	}
+
+	for source, want := range map[string]string{
+		"req.Self().Tags;":            "Undefined property",
+		"req.Self().Self().Origin.X;": "Undefined property",
+		`req.Self().Method = "POST";`: "Can't assign to field",
+	} {
+		err := evalError(t, vm, source)
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Eval(%q) error = %v, want %q", source, err, want)
+		}
+	}
+
+	if req.Method != "GET" {
+		t.Errorf("request is %+v, want it unchanged", req)
+	}
+}
+
+func TestHostString(t *testing.T) {
+	vm := NewVM(Options{})
+	for name, value := range map[string]interface{}{
+		"named": &named{"lox"},
+		"plain": &point{1, 2},
+	} {
+		if err := vm.SetGlobal(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]string{
+		"named;": "named lox",
+		"plain;": "<host glox.point>",
+	}
+
+	for source, want := range tests {
+		if got := mustEval(t, vm, source).String(); got != want {
+			t.Errorf("Eval(%q) = %s, want %s", source, got, want)
+		}
+	}
+}
+
+func TestHostNilPointer(t *testing.T) {
+	host := NewHost((*named)(nil), HostOptions{})
+	if !host.IsNil() {
+		t.Errorf("NewHost(nil pointer) = %s, want nil", host)
+	}
+}
+
+func TestHostAsArgument(t *testing.T) {
+	vm := NewVM(Options{})
+	req := newRequest()
+	if err := vm.SetGlobal("req", req); err != nil {
+		t.Fatal(err)
+	}
+
+	var got *request
+	mustRegister(t, vm, "take", func(r *request) { got = r })
+	mustRegister(t, vm, "origin", func(p point) int { return p.X })
+	mustEval(t, vm, "take(req); origin(req.Origin);")
+
+	if got != req {
+		t.Errorf("take got %p, want the wrapped request %p", got, req)
+	}
+
+	evalError(t, vm, "origin(req);")
+}
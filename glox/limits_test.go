@@ -0,0 +1,144 @@
+package glox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func limitError(t *testing.T, err error, limit Limit) *LimitError {
+	t.Helper()
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("err = %v, want a *LimitError", err)
+	}
+
+	if limitErr.Limit != limit {
+		t.Errorf("Limit = %d, want %d", limitErr.Limit, limit)
+	}
+
+	return limitErr
+}
+
+func TestTimeout(t *testing.T) {
+	vm := NewVM(Options{Timeout: 20 * time.Millisecond})
+
+	_, err := vm.Eval(context.Background(), "while (true) {}")
+	limitError(t, err, Cancelled)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want it to wrap context.DeadlineExceeded", err)
+	}
+
+	// the timeout applies to each Eval afresh
+	mustEval(t, vm, "1;")
+}
+
+func TestContextCancellation(t *testing.T) {
+	vm := NewVM(Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	mustRegister(t, vm, "cancel", cancel)
+
+	_, err := vm.Eval(ctx, "{ cancel(); while (true) {} }")
+	limitError(t, err, Cancelled)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want it to wrap context.Canceled", err)
+	}
+
+	// a cancelled script can't catch its way out
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = vm.Eval(ctx, "while (true) { try { while (true) {} } catch (e) {} }")
+	limitError(t, err, Cancelled)
+}
+
+func TestCallContext(t *testing.T) {
+	vm := NewVM(Options{})
+	mustEval(t, vm, "fun spin() { while (true) {} }")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := vm.CallContext(ctx, "spin")
+	limitError(t, err, Cancelled)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = vm.CallContext(cancelled, "spin")
+	limitError(t, err, Cancelled)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	vm := NewVM(Options{MaxSteps: 3})
+
+	_, err := vm.Eval(context.Background(), "{ var a = 1; a = 2; a = 3; a = 4; }")
+	limitError(t, err, StepLimit)
+
+	// each Eval has a budget of its own
+	mustEval(t, vm, "1;")
+
+	_, err = vm.Eval(context.Background(), "while (true) { try { 1; } catch (e) {} }")
+	limitError(t, err, StepLimit)
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	vm := NewVM(Options{MaxCallDepth: 10})
+	mustEval(t, vm, "fun down(n) { if (n > 0) down(n - 1); }")
+	mustEval(t, vm, "down(9);")
+
+	_, err := vm.Eval(context.Background(), "down(10);")
+	limitError(t, err, CallDepthLimit)
+
+	// scripts can recover from going too deep
+	got := mustEval(t, vm, `var m; try { down(100); } catch (e) { m = e.message; } m;`)
+	if got.String() != "Maximum call depth exceeded." {
+		t.Errorf("caught %s, want the limit's message", got)
+	}
+}
+
+func TestMaxLoopIterations(t *testing.T) {
+	vm := NewVM(Options{MaxLoopIterations: 10})
+
+	got := mustEval(t, vm, "var n = 0; for (var i = 0; i < 10; i = i + 1) { n = n + 1; } n;")
+	if got.String() != "10" {
+		t.Errorf("n = %s, want 10", got)
+	}
+
+	_, err := vm.Eval(context.Background(), "var i = 0;\nwhile (true) { i = i + 1; }")
+	if limitErr := limitError(t, err, LoopLimit); limitErr.Line != 2 {
+		t.Errorf("Line = %d, want 2", limitErr.Line)
+	}
+
+	got = mustEval(t, vm, "var m; try { while (true) {} } catch (e) { m = e.message; } m;")
+	if !strings.Contains(got.String(), "Loop iteration limit exceeded.") {
+		t.Errorf("caught %s, want the limit's message", got)
+	}
+}
+
+func TestDefaultMaxCallDepth(t *testing.T) {
+	vm := NewVM(Options{})
+
+	_, err := vm.Eval(context.Background(), "fun f() { f(); } f();")
+	limitError(t, err, CallDepthLimit)
+}
+
+func TestMaxStepsAtTopLevel(t *testing.T) {
+	vm := NewVM(Options{MaxSteps: 100})
+
+	_, err := vm.Eval(context.Background(), strings.Repeat("var a = 1;\n", 200))
+	if limitErr := limitError(t, err, StepLimit); limitErr.Line != 101 {
+		t.Errorf("Line = %d, want 101", limitErr.Line)
+	}
+
+	_, err = vm.Eval(context.Background(), "var a = 1;\n{\n"+strings.Repeat("a = 2;\n", 200)+"}")
+	if limitErr := limitError(t, err, StepLimit); limitErr.Line != 2 {
+		t.Errorf("Line = %d, want the block's line 2", limitErr.Line)
+	}
+}
@@ -0,0 +1,260 @@
+package glox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/dmcg310/glox/src/lox"
+)
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	valueType = reflect.TypeOf(Value{})
+	bigType   = reflect.TypeOf((*big.Int)(nil))
+	ratType   = reflect.TypeOf((*big.Rat)(nil))
+)
+
+// Register defines a global that calls the Go function fn. Arguments are
+// converted from Lox values to fn's parameter types, and a variadic fn
+// takes any number of trailing arguments. fn may return nothing, a value,
+// an error, or a value and an error; a non-nil error, like an argument that
+// can't be converted, becomes a runtime error the script can catch.
+func (vm *VM) Register(name string, fn interface{}) error {
+	function := reflect.ValueOf(fn)
+	if function.Kind() != reflect.Func || function.IsNil() {
+		return fmt.Errorf("glox: can't register %T as a function", fn)
+	}
+
+	native, err := nativeFunction(name, function, HostOptions{})
+	if err != nil {
+		return fmt.Errorf("glox: can't register '%s': %w", name, err)
+	}
+
+	vm.lox.SetGlobal(name, native)
+
+	return nil
+}
+
+// nativeFunction wraps a Go function, or a method value, for scripts to call.
+// Host objects among its results are given opts.
+func nativeFunction(name string, function reflect.Value, opts HostOptions) (*lox.NativeFunction, error) {
+	signature := function.Type()
+	if err := checkResults(signature); err != nil {
+		return nil, err
+	}
+
+	call := func(arguments []interface{}) (result interface{}, err error) {
+		// a panicking host function shouldn't take the whole program down
+		defer func() {
+			if r := recover(); r != nil {
+				result, err = nil, fmt.Errorf("%s panicked: %v", name, r)
+			}
+		}()
+
+		in, err := marshalArguments(name, signature, arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		return unmarshalResults(function.Call(in), opts)
+	}
+
+	if signature.IsVariadic() {
+		return lox.NewVariadicNativeFunction(name, signature.NumIn()-1, call), nil
+	}
+
+	return lox.NewNativeFunction(name, signature.NumIn(), call), nil
+}
+
+func checkResults(signature reflect.Type) error {
+	switch signature.NumOut() {
+	case 0, 1:
+		return nil
+	case 2:
+		if signature.Out(1) == errorType {
+			return nil
+		}
+	}
+
+	return errors.New("functions must return at most a value and an error")
+}
+
+func marshalArguments(name string, signature reflect.Type, arguments []interface{}) ([]reflect.Value, error) {
+	in := make([]reflect.Value, len(arguments))
+	for idx, argument := range arguments {
+		var paramType reflect.Type
+		if signature.IsVariadic() && idx >= signature.NumIn()-1 {
+			paramType = signature.In(signature.NumIn() - 1).Elem()
+		} else {
+			paramType = signature.In(idx)
+		}
+
+		converted, err := Value{raw: argument}.convert(paramType)
+		var mismatch *mismatchError
+		if errors.As(err, &mismatch) {
+			return nil, fmt.Errorf("Argument %d to '%s' must be %s but got %s.", idx+1, name, mismatch.expected, mismatch.got)
+		} else if err != nil {
+			return nil, fmt.Errorf("Argument %d to '%s': %s.", idx+1, name, err)
+		}
+
+		in[idx] = converted
+	}
+
+	return in, nil
+}
+
+func unmarshalResults(out []reflect.Value, opts HostOptions) (interface{}, error) {
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	last := out[len(out)-1]
+	if last.Type() == errorType {
+		if !last.IsNil() {
+			return nil, last.Interface().(error)
+		}
+
+		if len(out) == 1 {
+			return nil, nil
+		}
+	}
+
+	value, err := valueOf(out[0].Interface(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.raw, nil
+}
+
+// convert turns the value into a Go value of type target, as an argument to
+// a registered function.
+func (v Value) convert(target reflect.Type) (reflect.Value, error) {
+	if target == valueType {
+		return reflect.ValueOf(v), nil
+	}
+
+	// nil stands in for the zero value of anything that can be nil
+	if v.raw == nil {
+		switch target.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(target), nil
+		}
+	}
+
+	if host, ok := v.raw.(*hostObject); ok {
+		if value, ok := host.assignable(target); ok {
+			return value, nil
+		}
+	}
+
+	switch target {
+	case bigType:
+		integer, err := v.AsBigInt()
+		return reflect.ValueOf(integer), err
+	case ratType:
+		rat, err := v.AsRat()
+		return reflect.ValueOf(rat), err
+	}
+
+	switch target.Kind() {
+	case reflect.Interface:
+		converted := reflect.ValueOf(v.Interface())
+		if !converted.Type().AssignableTo(target) {
+			return reflect.Value{}, v.mismatch(target.String())
+		}
+
+		result := reflect.New(target).Elem()
+		result.Set(converted)
+
+		return result, nil
+	case reflect.Bool:
+		b, err := v.AsBool()
+		return reflect.ValueOf(b).Convert(target), err
+	case reflect.String:
+		s, err := v.AsString()
+		return reflect.ValueOf(s).Convert(target), err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		integer, err := v.AsInt()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		result := reflect.New(target).Elem()
+		if result.OverflowInt(integer) {
+			return reflect.Value{}, fmt.Errorf("%d doesn't fit in %s", integer, target)
+		}
+
+		result.SetInt(integer)
+
+		return result, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		integer, err := v.AsBigInt()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		result := reflect.New(target).Elem()
+		if !integer.IsUint64() || result.OverflowUint(integer.Uint64()) {
+			return reflect.Value{}, fmt.Errorf("%s doesn't fit in %s", integer, target)
+		}
+
+		result.SetUint(integer.Uint64())
+
+		return result, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := v.AsFloat()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		if target.Kind() == reflect.Float32 && math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return reflect.Value{}, fmt.Errorf("%v doesn't fit in %s", f, target)
+		}
+
+		return reflect.ValueOf(f).Convert(target), nil
+	case reflect.Slice:
+		elements, err := v.AsList()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		result := reflect.MakeSlice(target, len(elements), len(elements))
+		for idx, element := range elements {
+			converted, err := element.convert(target.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			result.Index(idx).Set(converted)
+		}
+
+		return result, nil
+	case reflect.Map:
+		if target.Key().Kind() != reflect.String {
+			break
+		}
+
+		entries, err := v.AsMap()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		result := reflect.MakeMapWithSize(target, len(entries))
+		for key, entry := range entries {
+			converted, err := entry.convert(target.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			result.SetMapIndex(reflect.ValueOf(key).Convert(target.Key()), converted)
+		}
+
+		return result, nil
+	}
+
+	return reflect.Value{}, v.mismatch(target.String())
+}
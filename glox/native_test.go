@@ -0,0 +1,230 @@
+package glox
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustRegister(t *testing.T, vm *VM, name string, fn interface{}) {
+	t.Helper()
+
+	if err := vm.Register(name, fn); err != nil {
+		t.Fatalf("Register(%s): %v", name, err)
+	}
+}
+
+func evalError(t *testing.T, vm *VM, source string) error {
+	t.Helper()
+
+	_, err := vm.Eval(context.Background(), source)
+	if err == nil {
+		t.Fatalf("Eval(%q) succeeded, want an error", source)
+	}
+
+	return err
+}
+
+func TestRegister(t *testing.T) {
+	vm := NewVM(Options{})
+	mustRegister(t, vm, "upper", strings.ToUpper)
+	mustRegister(t, vm, "nothing", func() {})
+
+	if got := mustEval(t, vm, `upper("lox");`); got.String() != "LOX" {
+		t.Errorf("upper = %s, want LOX", got)
+	}
+
+	if got := mustEval(t, vm, "nothing();"); !got.IsNil() {
+		t.Errorf("nothing() = %s, want nil", got)
+	}
+
+	err := evalError(t, vm, "upper(1);")
+	if !strings.Contains(err.Error(), "Argument 1 to 'upper' must be a string but got int.") {
+		t.Errorf("err = %v, want an argument mismatch", err)
+	}
+
+	err = evalError(t, vm, `upper("a", "b");`)
+	if !strings.Contains(err.Error(), "Expected 1 arguments but got 2.") {
+		t.Errorf("err = %v, want an arity error", err)
+	}
+}
+
+func TestRegisterRejectsUnsupportedFunctions(t *testing.T) {
+	vm := NewVM(Options{})
+
+	for _, fn := range []interface{}{
+		"not a function",
+		(func())(nil),
+		func() (int, int) { return 0, 0 },
+		func() (int, string, error) { return 0, "", nil },
+	} {
+		if err := vm.Register("fn", fn); err == nil {
+			t.Errorf("Register accepted %T", fn)
+		}
+	}
+}
+
+func TestRegisterVariadic(t *testing.T) {
+	vm := NewVM(Options{})
+	mustRegister(t, vm, "join", func(separator string, parts ...string) string {
+		return strings.Join(parts, separator)
+	})
+
+	if got := mustEval(t, vm, `join("-");`); got.String() != "" {
+		t.Errorf(`join("-") = %q, want ""`, got)
+	}
+
+	if got := mustEval(t, vm, `join("-", "a", "b", "c");`); got.String() != "a-b-c" {
+		t.Errorf("join = %s, want a-b-c", got)
+	}
+
+	err := evalError(t, vm, "join();")
+	if !strings.Contains(err.Error(), "Expected at least 1 arguments but got 0.") {
+		t.Errorf("err = %v, want an arity error", err)
+	}
+
+	err = evalError(t, vm, `join("-", "a", 2);`)
+	if !strings.Contains(err.Error(), "Argument 3 to 'join'") {
+		t.Errorf("err = %v, want a mismatch on the third argument", err)
+	}
+}
+
+func TestRegisterErrorReturns(t *testing.T) {
+	vm := NewVM(Options{})
+	mustRegister(t, vm, "parse", func(s string) (int, error) {
+		if s == "" {
+			return 0, errors.New("empty input")
+		}
+
+		return len(s), nil
+	})
+	mustRegister(t, vm, "check", func(ok bool) error {
+		if !ok {
+			return errors.New("check failed")
+		}
+
+		return nil
+	})
+
+	if got := mustEval(t, vm, `parse("abc");`); got.String() != "3" {
+		t.Errorf("parse = %s, want 3", got)
+	}
+
+	if got := mustEval(t, vm, "check(true);"); !got.IsNil() {
+		t.Errorf("check(true) = %s, want nil", got)
+	}
+
+	var runtimeErr *RuntimeError
+	err := evalError(t, vm, "\ncheck(false);")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Message != "check failed" || runtimeErr.Line != 2 {
+		t.Errorf("err = %v, want check failed at line 2", err)
+	}
+
+	// the error is a Lox runtime error, so scripts can catch it
+	got := mustEval(t, vm, `var m; try { parse(""); } catch (e) { m = e.message; } m;`)
+	if got.String() != "empty input" {
+		t.Errorf("caught %s, want empty input", got)
+	}
+}
+
+func TestRegisterRecoversPanics(t *testing.T) {
+	vm := NewVM(Options{})
+	mustRegister(t, vm, "explode", func() { panic("boom") })
+
+	err := evalError(t, vm, "explode();")
+	if !strings.Contains(err.Error(), "explode panicked: boom") {
+		t.Errorf("err = %v, want the panic as an error", err)
+	}
+
+	// the VM is still usable afterwards
+	mustEval(t, vm, "1;")
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func TestConvert(t *testing.T) {
+	vm := NewVM(Options{})
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	tests := []struct {
+		source string
+		target reflect.Type
+		want   interface{}
+	}{
+		{"7;", typeOf[int8](), int8(7)},
+		{"7.0;", typeOf[int](), 7},
+		{"7n;", typeOf[uint16](), uint16(7)},
+		{"2.5d;", typeOf[float64](), 2.5},
+		{"3;", typeOf[float32](), float32(3)},
+		{"true;", typeOf[bool](), true},
+		{`"s";`, typeOf[string](), "s"},
+		{"123456789012345678901234567890n;", typeOf[*big.Int](), huge},
+		{"0.25d;", typeOf[*big.Rat](), big.NewRat(1, 4)},
+		{"[1, 2];", typeOf[[]int](), []int{1, 2}},
+		{`[["a"], []];`, typeOf[[][]string](), [][]string{{"a"}, {}}},
+		{`[1, "a", nil];`, typeOf[interface{}](), []interface{}{int64(1), "a", nil}},
+		{`var m = {"a": 1.5}; m;`, typeOf[map[string]float64](), map[string]float64{"a": 1.5}},
+		{"nil;", typeOf[*big.Int](), (*big.Int)(nil)},
+		{"nil;", typeOf[[]string](), []string(nil)},
+		{"nil;", typeOf[map[string]int](), map[string]int(nil)},
+		{"nil;", typeOf[interface{}](), nil},
+	}
+
+	for _, test := range tests {
+		got, err := mustEval(t, vm, test.source).convert(test.target)
+		if err != nil {
+			t.Errorf("convert(%s) to %s: %v", test.source, test.target, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got.Interface(), test.want) {
+			t.Errorf("convert(%s) to %s = %#v, want %#v", test.source, test.target, got.Interface(), test.want)
+		}
+	}
+}
+
+func TestConvertRejectsMismatches(t *testing.T) {
+	vm := NewVM(Options{})
+
+	tests := []struct {
+		source string
+		target reflect.Type
+	}{
+		{"300;", typeOf[int8]()},
+		{"-1;", typeOf[uint]()},
+		{"1.5;", typeOf[int]()},
+		{`"1";`, typeOf[int]()},
+		{"1;", typeOf[bool]()},
+		{"1e300;", typeOf[float32]()},
+		{"nil;", typeOf[int]()},
+		{"nil;", typeOf[string]()},
+		{"1;", typeOf[[]int]()},
+		{`["a", ["b"]];`, typeOf[[][]string]()},
+		{`var m = {1: 1}; m;`, typeOf[map[string]int]()},
+		{`var m = {"a": 1}; m;`, typeOf[map[int]int]()},
+		{"1;", typeOf[chan int]()},
+	}
+
+	for _, test := range tests {
+		if _, err := mustEval(t, vm, test.source).convert(test.target); err == nil {
+			t.Errorf("convert(%s) to %s succeeded, want an error", test.source, test.target)
+		}
+	}
+}
+
+func TestConvertPassesValuesThrough(t *testing.T) {
+	vm := NewVM(Options{})
+
+	var got Value
+	mustRegister(t, vm, "keep", func(value Value) { got = value })
+	mustEval(t, vm, "fun f() {} keep(f);")
+
+	if got.Kind() != Function {
+		t.Errorf("keep got a %s, want the function itself", got.Kind())
+	}
+}
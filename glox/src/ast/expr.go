@@ -5,6 +5,7 @@ import "github.com/dmcg310/glox/src/token"
 type Expr interface {
 	Accept(visitor Visitor) (interface{}, error)
 }
+
 type Assign struct {
 	Name  token.Token
 	Value Expr
@@ -24,6 +25,46 @@ func (expr *Binary) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitBinary(expr)
 }
 
+type Call struct {
+	Callee    Expr
+	Paren     token.Token
+	Arguments []Expr
+}
+
+func (expr *Call) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCall(expr)
+}
+
+type CompoundAssign struct {
+	Target   Expr
+	Operator token.Token
+	Value    Expr
+}
+
+func (expr *CompoundAssign) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCompoundAssign(expr)
+}
+
+type DestructureAssign struct {
+	Bracket token.Token
+	Targets []Expr
+	Rest    Expr
+	Value   Expr
+}
+
+func (expr *DestructureAssign) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDestructureAssign(expr)
+}
+
+type Get struct {
+	Object Expr
+	Name   token.Token
+}
+
+func (expr *Get) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitGet(expr)
+}
+
 type Grouping struct {
 	Expression Expr
 }
@@ -32,6 +73,62 @@ func (expr *Grouping) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitGrouping(expr)
 }
 
+type Increment struct {
+	Target   Expr
+	Operator token.Token
+	Prefix   bool
+}
+
+func (expr *Increment) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIncrement(expr)
+}
+
+type Index struct {
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+}
+
+func (expr *Index) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndex(expr)
+}
+
+type IndexSet struct {
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+	Value   Expr
+}
+
+func (expr *IndexSet) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexSet(expr)
+}
+
+type Interpolation struct {
+	Parts []Expr
+}
+
+func (expr *Interpolation) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitInterpolation(expr)
+}
+
+type Lambda struct {
+	Function *Function
+}
+
+func (expr *Lambda) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitLambda(expr)
+}
+
+type ListLiteral struct {
+	Bracket  token.Token
+	Elements []Expr
+}
+
+func (expr *ListLiteral) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitListLiteral(expr)
+}
+
 type Literal struct {
 	Value interface{}
 }
@@ -50,6 +147,63 @@ func (expr *Logical) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitLogical(expr)
 }
 
+type MapLiteral struct {
+	Brace  token.Token
+	Keys   []Expr
+	Values []Expr
+}
+
+func (expr *MapLiteral) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitMapLiteral(expr)
+}
+
+type Match struct {
+	Keyword token.Token
+	Subject Expr
+	Arms    []*MatchArm
+}
+
+func (expr *Match) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitMatch(expr)
+}
+
+type Set struct {
+	Object Expr
+	Name   token.Token
+	Value  Expr
+}
+
+func (expr *Set) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitSet(expr)
+}
+
+type Super struct {
+	Keyword token.Token
+	Method  token.Token
+}
+
+func (expr *Super) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitSuper(expr)
+}
+
+type Ternary struct {
+	Condition  Expr
+	ThenBranch Expr
+	ElseBranch Expr
+}
+
+func (expr *Ternary) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitTernary(expr)
+}
+
+type This struct {
+	Keyword token.Token
+}
+
+func (expr *This) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitThis(expr)
+}
+
 type Unary struct {
 	Operator token.Token
 	Right    Expr
@@ -0,0 +1,77 @@
+package ast
+
+import "github.com/dmcg310/glox/src/token"
+
+// Pattern is the test in a match arm, or the shape of a destructuring
+// declaration. Patterns aren't visited like expressions and statements; the
+// resolver and interpreter switch on the concrete type instead.
+type Pattern interface {
+	pattern()
+}
+
+// MatchArm is a single "case pattern if guard => body" in a match. Guard is
+// nil when the arm has none. Expression matches wrap their bodies in an
+// Expression statement.
+type MatchArm struct {
+	Keyword token.Token
+	Pattern Pattern
+	Guard   Expr
+	Body    Stmt
+}
+
+// WildcardPattern is "_", which matches anything without binding it.
+type WildcardPattern struct {
+	Token token.Token
+}
+
+// LiteralPattern matches a value equal to a number, string, boolean or nil.
+type LiteralPattern struct {
+	Token token.Token
+	Value interface{}
+}
+
+// BindingPattern matches anything and binds it to Name.
+type BindingPattern struct {
+	Name token.Token
+}
+
+// AlternativePattern matches when any of its alternatives do, as in
+// "a" | "b".
+type AlternativePattern struct {
+	Alternatives []Pattern
+}
+
+// ListPattern matches a list element by element. With HasRest set the list
+// may be longer, and Rest, if not nil, is matched against the remaining
+// elements as a new list.
+type ListPattern struct {
+	Bracket  token.Token
+	Elements []Pattern
+	HasRest  bool
+	Rest     Pattern
+}
+
+// ClassPattern matches an instance of Class, or of a subclass, whose fields
+// match the patterns given for them. A field written on its own binds it
+// to a variable of the same name.
+type ClassPattern struct {
+	Class    *Variable
+	Fields   []token.Token
+	Patterns []Pattern
+}
+
+// ObjectPattern matches an instance or map that has every field named,
+// matching each against the pattern given for it as ClassPattern does.
+type ObjectPattern struct {
+	Brace    token.Token
+	Fields   []token.Token
+	Patterns []Pattern
+}
+
+func (p *WildcardPattern) pattern()    {}
+func (p *LiteralPattern) pattern()     {}
+func (p *BindingPattern) pattern()     {}
+func (p *AlternativePattern) pattern() {}
+func (p *ListPattern) pattern()        {}
+func (p *ClassPattern) pattern()       {}
+func (p *ObjectPattern) pattern()      {}
@@ -11,7 +11,44 @@ type Block struct {
 }
 
 func (stmt *Block) Accept(visitor Visitor) (interface{}, error) {
-	return visitor.VisitBlock(stmt), nil
+	return visitor.VisitBlock(stmt)
+}
+
+type Break struct {
+	Keyword token.Token
+}
+
+func (stmt *Break) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitBreak(stmt)
+}
+
+type Class struct {
+	Name       token.Token
+	Superclass *Variable
+	Methods    []*Function
+}
+
+func (stmt *Class) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitClass(stmt)
+}
+
+type Continue struct {
+	Keyword token.Token
+}
+
+func (stmt *Continue) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitContinue(stmt)
+}
+
+type Destructure struct {
+	Keyword     token.Token
+	Pattern     Pattern
+	Initialiser Expr
+	Constant    bool
+}
+
+func (stmt *Destructure) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDestructure(stmt)
 }
 
 type Expression struct {
@@ -19,7 +56,17 @@ type Expression struct {
 }
 
 func (stmt *Expression) Accept(visitor Visitor) (interface{}, error) {
-	return visitor.VisitExpression(stmt), nil
+	return visitor.VisitExpression(stmt)
+}
+
+type Function struct {
+	Name   token.Token
+	Params []token.Token
+	Body   []Stmt
+}
+
+func (stmt *Function) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(stmt)
 }
 
 type If struct {
@@ -29,7 +76,28 @@ type If struct {
 }
 
 func (stmt *If) Accept(visitor Visitor) (interface{}, error) {
-	return visitor.VisitIf(stmt), nil
+	return visitor.VisitIf(stmt)
+}
+
+type Import struct {
+	Keyword token.Token
+	Path    token.Token
+	Alias   token.Token
+	Names   []token.Token
+}
+
+func (stmt *Import) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitImport(stmt)
+}
+
+type MatchStmt struct {
+	Keyword token.Token
+	Subject Expr
+	Arms    []*MatchArm
+}
+
+func (stmt *MatchStmt) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitMatchStmt(stmt)
 }
 
 type Print struct {
@@ -37,12 +105,43 @@ type Print struct {
 }
 
 func (stmt *Print) Accept(visitor Visitor) (interface{}, error) {
-	return visitor.VisitPrint(stmt), nil
+	return visitor.VisitPrint(stmt)
+}
+
+type Return struct {
+	Keyword token.Token
+	Value   Expr
+}
+
+func (stmt *Return) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitReturn(stmt)
+}
+
+type Throw struct {
+	Keyword token.Token
+	Value   Expr
+}
+
+func (stmt *Throw) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitThrow(stmt)
+}
+
+type Try struct {
+	Keyword     token.Token
+	Body        *Block
+	CatchName   token.Token
+	CatchBody   *Block
+	FinallyBody *Block
+}
+
+func (stmt *Try) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitTry(stmt)
 }
 
 type Var struct {
 	Name        token.Token
 	Initialiser Expr
+	Constant    bool
 }
 
 func (stmt *Var) Accept(visitor Visitor) (interface{}, error) {
@@ -50,8 +149,10 @@ func (stmt *Var) Accept(visitor Visitor) (interface{}, error) {
 }
 
 type While struct {
+	Keyword   token.Token
 	Condition Expr
 	Body      Stmt
+	Increment Expr
 }
 
 func (stmt *While) Accept(visitor Visitor) (interface{}, error) {
@@ -3,16 +3,42 @@ package ast
 type Visitor interface {
 	VisitAssign(expr *Assign) (interface{}, error)
 	VisitBinary(expr *Binary) (interface{}, error)
+	VisitCall(expr *Call) (interface{}, error)
+	VisitCompoundAssign(expr *CompoundAssign) (interface{}, error)
+	VisitDestructureAssign(expr *DestructureAssign) (interface{}, error)
+	VisitGet(expr *Get) (interface{}, error)
 	VisitGrouping(expr *Grouping) (interface{}, error)
+	VisitIncrement(expr *Increment) (interface{}, error)
+	VisitIndex(expr *Index) (interface{}, error)
+	VisitIndexSet(expr *IndexSet) (interface{}, error)
+	VisitInterpolation(expr *Interpolation) (interface{}, error)
+	VisitLambda(expr *Lambda) (interface{}, error)
+	VisitListLiteral(expr *ListLiteral) (interface{}, error)
 	VisitLiteral(expr *Literal) (interface{}, error)
 	VisitLogical(expr *Logical) (interface{}, error)
+	VisitMapLiteral(expr *MapLiteral) (interface{}, error)
+	VisitMatch(expr *Match) (interface{}, error)
+	VisitSet(expr *Set) (interface{}, error)
+	VisitSuper(expr *Super) (interface{}, error)
+	VisitTernary(expr *Ternary) (interface{}, error)
+	VisitThis(expr *This) (interface{}, error)
 	VisitUnary(expr *Unary) (interface{}, error)
 	VisitVariable(expr *Variable) (interface{}, error)
 
-	VisitBlock(expr *Block) error
-	VisitExpression(expr *Expression) error
-	VisitIf(expr *If) error
-	VisitPrint(expr *Print) error
+	VisitBlock(stmt *Block) (interface{}, error)
+	VisitBreak(stmt *Break) (interface{}, error)
+	VisitClass(stmt *Class) (interface{}, error)
+	VisitContinue(stmt *Continue) (interface{}, error)
+	VisitDestructure(stmt *Destructure) (interface{}, error)
+	VisitExpression(stmt *Expression) (interface{}, error)
+	VisitFunction(stmt *Function) (interface{}, error)
+	VisitIf(stmt *If) (interface{}, error)
+	VisitImport(stmt *Import) (interface{}, error)
+	VisitMatchStmt(stmt *MatchStmt) (interface{}, error)
+	VisitPrint(stmt *Print) (interface{}, error)
+	VisitReturn(stmt *Return) (interface{}, error)
+	VisitThrow(stmt *Throw) (interface{}, error)
+	VisitTry(stmt *Try) (interface{}, error)
 	VisitVar(stmt *Var) (interface{}, error)
 	VisitWhile(stmt *While) (interface{}, error)
 }
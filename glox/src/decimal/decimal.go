@@ -0,0 +1,210 @@
+package decimal
+
+import (
+	"math/big"
+	"strings"
+)
+
+// maxScale caps the significant digits shown when a quotient has no exact
+// finite representation, such as 1 / 3. Every other result keeps as many
+// digits as it needs.
+const maxScale = 34
+
+// Decimal is an exact base-10 number. The value is held as a rational so
+// arithmetic never rounds; scale is only the number of fractional digits
+// shown when printing, so 1.10 keeps its trailing zero.
+type Decimal struct {
+	rat   *big.Rat
+	scale int
+}
+
+func New(rat *big.Rat, scale int) *Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+
+	return &Decimal{
+		rat:   rat,
+		scale: scale,
+	}
+}
+
+// Parse reads a decimal literal such as "12.50" or "1.5e-3".
+func Parse(text string) (*Decimal, bool) {
+	text = strings.ReplaceAll(text, "_", "")
+
+	rat, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, false
+	}
+
+	mantissa, exponent := text, 0
+	if idx := strings.IndexAny(text, "eE"); idx >= 0 {
+		mantissa = text[:idx]
+		exp, ok := new(big.Int).SetString(text[idx+1:], 10)
+		if !ok || !exp.IsInt64() {
+			return nil, false
+		}
+
+		exponent = int(exp.Int64())
+	}
+
+	scale := 0
+	if idx := strings.IndexByte(mantissa, '.'); idx >= 0 {
+		scale = len(mantissa) - idx - 1
+	}
+
+	return New(rat, scale-exponent), true
+}
+
+// FromRat converts rat exactly when its decimal expansion terminates, and
+// to maxScale digits otherwise.
+func FromRat(rat *big.Rat) *Decimal {
+	return New(new(big.Rat).Set(rat), exactScale(rat, 0))
+}
+
+func FromInt(value *big.Int) *Decimal {
+	return New(new(big.Rat).SetInt(value), 0)
+}
+
+func (d *Decimal) Rat() *big.Rat {
+	return new(big.Rat).Set(d.rat)
+}
+
+func (d *Decimal) Add(other *Decimal) *Decimal {
+	return New(new(big.Rat).Add(d.rat, other.rat), max(d.scale, other.scale))
+}
+
+func (d *Decimal) Sub(other *Decimal) *Decimal {
+	return New(new(big.Rat).Sub(d.rat, other.rat), max(d.scale, other.scale))
+}
+
+func (d *Decimal) Mul(other *Decimal) *Decimal {
+	return New(new(big.Rat).Mul(d.rat, other.rat), d.scale+other.scale)
+}
+
+// Quo divides exactly, returning false for a zero divisor.
+func (d *Decimal) Quo(other *Decimal) (*Decimal, bool) {
+	if other.rat.Sign() == 0 {
+		return nil, false
+	}
+
+	quotient := new(big.Rat).Quo(d.rat, other.rat)
+
+	return New(quotient, exactScale(quotient, max(d.scale, other.scale))), true
+}
+
+// Rem returns the remainder of truncated division, taking the sign of d.
+func (d *Decimal) Rem(other *Decimal) (*Decimal, bool) {
+	if other.rat.Sign() == 0 {
+		return nil, false
+	}
+
+	quotient := new(big.Rat).Quo(d.rat, other.rat)
+	truncated := new(big.Int).Quo(quotient.Num(), quotient.Denom())
+	product := new(big.Rat).Mul(other.rat, new(big.Rat).SetInt(truncated))
+
+	return New(new(big.Rat).Sub(d.rat, product), max(d.scale, other.scale)), true
+}
+
+// Pow raises d to an integer power, returning false for a negative power of
+// zero.
+func (d *Decimal) Pow(exponent int64) (*Decimal, bool) {
+	result := New(big.NewRat(1, 1), 0)
+	base := d
+
+	negative := exponent < 0
+	if negative {
+		exponent = -exponent
+	}
+
+	for ; exponent > 0; exponent >>= 1 {
+		if exponent&1 == 1 {
+			result = result.Mul(base)
+		}
+
+		if exponent > 1 {
+			base = base.Mul(base)
+		}
+	}
+
+	if negative {
+		return New(big.NewRat(1, 1), 0).Quo(result)
+	}
+
+	return result, true
+}
+
+// Scale is the number of fractional digits d prints with.
+func (d *Decimal) Scale() int {
+	return d.scale
+}
+
+func (d *Decimal) Neg() *Decimal {
+	return New(new(big.Rat).Neg(d.rat), d.scale)
+}
+
+func (d *Decimal) Cmp(other *Decimal) int {
+	return d.rat.Cmp(other.rat)
+}
+
+func (d *Decimal) IsInt() bool {
+	return d.rat.IsInt()
+}
+
+// Int truncates d towards zero.
+func (d *Decimal) Int() *big.Int {
+	return new(big.Int).Quo(d.rat.Num(), d.rat.Denom())
+}
+
+func (d *Decimal) Float64() float64 {
+	f, _ := d.rat.Float64()
+	return f
+}
+
+func (d *Decimal) String() string {
+	return d.rat.FloatString(d.scale)
+}
+
+// exactScale finds the fewest fractional digits, no fewer than least, that
+// represent rat exactly. That is the larger power of 2 or 5 in its
+// denominator, and if the denominator has any other factor the expansion
+// doesn't terminate and only maxScale significant digits are kept.
+func exactScale(rat *big.Rat, least int) int {
+	denom := new(big.Int).Set(rat.Denom())
+	twos := int(denom.TrailingZeroBits())
+	denom.Rsh(denom, uint(twos))
+
+	fives := 0
+	five, remainder := big.NewInt(5), new(big.Int)
+	for {
+		quotient, _ := new(big.Int).QuoRem(denom, five, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+
+		denom = quotient
+		fives++
+	}
+
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return max(least, leadingZeros(rat)+maxScale)
+	}
+
+	return max(least, twos, fives)
+}
+
+// leadingZeros counts the zeros between the decimal point and the first
+// significant digit of rat, which is zero when |rat| >= 0.1.
+func leadingZeros(rat *big.Rat) int {
+	scaled := new(big.Rat).Abs(rat)
+	tenth, ten := big.NewRat(1, 10), big.NewRat(10, 1)
+
+	zeros := 0
+	for scaled.Sign() != 0 && scaled.Cmp(tenth) < 0 {
+		scaled.Mul(scaled, ten)
+		zeros++
+	}
+
+	return zeros
+}
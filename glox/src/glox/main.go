@@ -3,25 +3,35 @@ package main
 import (
 	"fmt"
 	"github.com/dmcg310/glox/src/lox"
-	"github.com/dmcg310/glox/src/report"
+	"log"
 	"os"
+	"path/filepath"
 )
 
 func main() {
-	reporter := &report.LoxReporter{}
 	l := lox.Lox{
-		Interpreter:     lox.Interpreter{},
+		Interpreter:     lox.NewInterpreter(),
 		HadError:        false,
 		HadRuntimeError: false,
-		Reporter:        reporter,
+		SearchPath:      filepath.SplitList(os.Getenv("GLOX_PATH")),
 	}
 
 	if len(os.Args) > 2 {
 		fmt.Println("Usage: glox [script]")
 		os.Exit(64)
 	} else if len(os.Args) == 2 {
-		l.RunFile(os.Args[1])
-	} else {
-		l.RunPrompt()
+		if err := l.RunFile(os.Args[1]); err != nil {
+			log.Fatal(err)
+		}
+
+		if l.HadError {
+			os.Exit(65)
+		}
+
+		if l.HadRuntimeError {
+			os.Exit(70)
+		}
+	} else if err := l.RunPrompt(); err != nil {
+		log.Fatal(err)
 	}
 }
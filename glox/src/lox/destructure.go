@@ -0,0 +1,159 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dmcg310/glox/src/ast"
+	"github.com/dmcg310/glox/src/token"
+)
+
+func (i *Interpreter) VisitDestructure(stmt *ast.Destructure) (interface{}, error) {
+	value, err := i.evaluate(stmt.Initialiser)
+	if err != nil {
+		return nil, err
+	}
+
+	define := i.Environment.define
+	if stmt.Constant {
+		define = i.Environment.defineConstant
+	}
+
+	return nil, i.destructure(stmt.Pattern, value, define)
+}
+
+// destructure takes value apart according to pattern, handing each name the
+// pattern binds to define. A value of the wrong shape is a runtime error at
+// the pattern that didn't fit.
+func (i *Interpreter) destructure(pattern ast.Pattern, value interface{}, define func(string, interface{})) error {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return nil
+	case *ast.BindingPattern:
+		define(pattern.Name.Lexeme, value)
+		return nil
+	case *ast.ListPattern:
+		elements, err := i.destructureList(pattern.Bracket, len(pattern.Elements), pattern.HasRest, value)
+		if err != nil {
+			return err
+		}
+
+		for idx, element := range pattern.Elements {
+			if err := i.destructure(element, elements[idx], define); err != nil {
+				return err
+			}
+		}
+
+		if pattern.Rest != nil {
+			return i.destructure(pattern.Rest, restOf(elements, len(pattern.Elements)), define)
+		}
+
+		return nil
+	case *ast.ObjectPattern:
+		for idx, field := range pattern.Fields {
+			fieldValue, err := i.destructureField(pattern.Brace, field, value)
+			if err != nil {
+				return err
+			}
+
+			if err := i.destructure(pattern.Patterns[idx], fieldValue, define); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("invalid destructuring pattern: %T", pattern)
+}
+
+func (i *Interpreter) VisitDestructureAssign(expr *ast.DestructureAssign) (interface{}, error) {
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	elements, err := i.destructureList(expr.Bracket, len(expr.Targets), expr.Rest != nil, value)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, target := range expr.Targets {
+		if err := i.assignTarget(target, elements[idx]); err != nil {
+			return nil, err
+		}
+	}
+
+	if expr.Rest != nil {
+		if err := i.assignTarget(expr.Rest, restOf(elements, len(expr.Targets))); err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+func (i *Interpreter) assignTarget(target ast.Expr, value interface{}) error {
+	_, set, err := i.reference(target)
+	if err != nil {
+		return err
+	}
+
+	return set(value)
+}
+
+// destructureList checks that value is a list with count elements, or at
+// least count when there is a rest pattern, and returns its elements.
+func (i *Interpreter) destructureList(bracket token.Token, count int, hasRest bool, value interface{}) ([]interface{}, error) {
+	list, ok := value.(*LoxList)
+	if !ok {
+		return nil, &RuntimeError{Token: bracket, Msg: "Can only destructure a list with '['."}
+	}
+
+	if hasRest && len(list.Elements) < count {
+		return nil, &RuntimeError{
+			Token: bracket,
+			Msg:   "Expected at least " + elementCount(count) + " but got " + strconv.Itoa(len(list.Elements)) + ".",
+		}
+	}
+
+	if !hasRest && len(list.Elements) != count {
+		return nil, &RuntimeError{
+			Token: bracket,
+			Msg:   "Expected " + elementCount(count) + " but got " + strconv.Itoa(len(list.Elements)) + ".",
+		}
+	}
+
+	return list.Elements, nil
+}
+
+func elementCount(count int) string {
+	if count == 1 {
+		return "1 element"
+	}
+
+	return strconv.Itoa(count) + " elements"
+}
+
+// destructureField reads a field of an instance or host object, or a
+// string key of a map.
+func (i *Interpreter) destructureField(brace token.Token, field token.Token, value interface{}) (interface{}, error) {
+	switch object := value.(type) {
+	case *LoxInstance:
+		return object.get(field)
+	case *LoxMap:
+		return object.get(field, field.Lexeme)
+	case HostObject:
+		return getProperty(object, field)
+	}
+
+	return nil, &RuntimeError{Token: brace, Msg: "Can only destructure instances and maps with '{'."}
+}
+
+// restOf copies the elements after the first count into a new list.
+func restOf(elements []interface{}, count int) *LoxList {
+	rest := make([]interface{}, len(elements)-count)
+	copy(rest, elements[count:])
+
+	return NewLoxList(rest)
+}
@@ -0,0 +1,40 @@
+package lox
+
+import "testing"
+
+func TestDestructuring(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "list", source: "var [a, b] = [1, 2]; print a + b;", want: "3\n"},
+		{name: "rest", source: "var [first, ...rest] = [1, 2, 3]; print first; print rest;", want: "1\n[2, 3]\n"},
+		{name: "wildcards", source: "var [_, second, ..._] = [1, 2, 3, 4]; print second;", want: "2\n"},
+		{name: "nested", source: "var [a, [b, c]] = [1, [2, 3]]; print a + b + c;", want: "6\n"},
+		{
+			name:   "instance fields",
+			source: "class P { init(x, y) { this.x = x; this.y = y; } } var {x, y: [first]} = P(1, [2]); print x; print first;",
+			want:   "1\n2\n",
+		},
+		{name: "map keys", source: `var {name} = {"name": "lox"}; print name;`, want: "lox\n"},
+		{name: "const", source: "const [a] = [1]; a = 2;", err: "Can't assign to constant 'a'."},
+		{name: "swap", source: "var a = 1; var b = 2; [a, b] = [b, a]; print a; print b;", want: "[2, 1]\n2\n1\n"},
+		{
+			name:   "assign to fields and indexes",
+			source: "class Box {} var box = Box(); var l = [0]; [box.v, l[0], ...box.rest] = [1, 2, 3]; print box.v + l[0]; print box.rest;",
+			want:   "[1, 2, 3]\n3\n[3]\n",
+		},
+		{name: "local", source: "fun f() { var [a, b] = [1, 2]; return a * b; } print f();", want: "2\n"},
+		{
+			name:   "too few elements",
+			source: "var [a, b] = [1];",
+			err:    "Expected 2 elements but got 1.",
+		},
+		{
+			name:   "too few for a rest pattern",
+			source: "var [a, ...rest] = [];",
+			err:    "Expected at least 1 element but got 0.",
+		},
+		{name: "not a list", source: "var [a] = 1;", err: "Can only destructure a list with '['."},
+		{name: "not an object", source: "var {a} = [1];", err: "Can only destructure instances and maps with '{'."},
+		{name: "missing field", source: "class P {} var {x} = P();", err: "Undefined property 'x'."},
+		{name: "invalid target", source: "[1, a] = [1, 2];", err: "Invalid assignment target."},
+	})
+}
@@ -0,0 +1,78 @@
+package lox
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCompile is returned by Eval when source had scanning, parsing or
+// resolution errors, which have already been passed to the Reporter.
+var ErrCompile = errors.New("compile error")
+
+// Global returns the value of a global variable in the main script.
+func (l *Lox) Global(name string) (interface{}, bool) {
+	value, ok := l.Interpreter.Globals.values[name]
+	if !ok {
+		// natives live in the enclosing builtins environment
+		value, ok = l.Interpreter.builtins.values[name]
+	}
+
+	return value, ok
+}
+
+// SetGlobal defines, or redefines, a global variable in the main script.
+func (l *Lox) SetGlobal(name string, value interface{}) {
+	l.Interpreter.Globals.define(name, value)
+}
+
+// Call calls a Lox function, class or native from Go.
+func (l *Lox) Call(callee interface{}, arguments []interface{}) (interface{}, error) {
+	return l.CallContext(context.Background(), callee, arguments)
+}
+
+// CallContext is Call for a call that stops with a LimitError once ctx is
+// done.
+func (l *Lox) CallContext(ctx context.Context, callee interface{}, arguments []interface{}) (interface{}, error) {
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		return nil, errors.New("can only call functions and classes")
+	}
+
+	if msg := arityMismatch(function, len(arguments)); msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	l.Interpreter.Importer = l
+	l.Interpreter.Stdout = l.stdout()
+
+	end := l.Interpreter.begin(ctx)
+	defer end()
+
+	return function.Call(&l.Interpreter, arguments)
+}
+
+// NewNativeFunction wraps a Go function so that scripts can call it. A
+// non-nil error from fn becomes a runtime error at the call site.
+func NewNativeFunction(name string, arity int, fn func(arguments []interface{}) (interface{}, error)) *NativeFunction {
+	return &NativeFunction{
+		Name:  name,
+		arity: arity,
+		fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return fn(arguments)
+		},
+	}
+}
+
+// NewVariadicNativeFunction is NewNativeFunction for a function taking at
+// least arity arguments.
+func NewVariadicNativeFunction(name string, arity int, fn func(arguments []interface{}) (interface{}, error)) *NativeFunction {
+	native := NewNativeFunction(name, arity, fn)
+	native.variadic = true
+
+	return native
+}
+
+// Stringify formats a value the way print shows it.
+func Stringify(value interface{}) string {
+	return stringify(value)
+}
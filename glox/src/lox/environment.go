@@ -4,12 +4,14 @@ import "github.com/dmcg310/glox/src/token"
 
 type Environment struct {
 	values    map[string]interface{}
+	constants map[string]bool
 	enclosing *Environment
 }
 
-func NewEnvironment(enclosing ...*Environment) Environment {
-	env := Environment{
-		values: make(map[string]interface{}),
+func NewEnvironment(enclosing ...*Environment) *Environment {
+	env := &Environment{
+		values:    make(map[string]interface{}),
+		constants: make(map[string]bool),
 	}
 
 	// function overloading :(
@@ -37,6 +39,10 @@ func (e *Environment) get(name token.Token) (interface{}, *RuntimeError) {
 
 func (e *Environment) assign(name token.Token, newVal interface{}) *RuntimeError {
 	if _, ok := e.values[name.Lexeme]; ok {
+		if e.constants[name.Lexeme] {
+			return constantAssignment(name)
+		}
+
 		e.values[name.Lexeme] = newVal
 
 		return nil
@@ -59,4 +65,43 @@ func (e *Environment) assign(name token.Token, newVal interface{}) *RuntimeError
 
 func (e *Environment) define(name string, value interface{}) {
 	e.values[name] = value
+	delete(e.constants, name)
+}
+
+// defineConstant binds a name that assign will refuse to change. Defining
+// the name again, as redeclaring a global does, replaces the binding.
+func (e *Environment) defineConstant(name string, value interface{}) {
+	e.values[name] = value
+	e.constants[name] = true
+}
+
+func (e *Environment) getAt(distance int, name string) interface{} {
+	return e.ancestor(distance).values[name]
+}
+
+func (e *Environment) assignAt(distance int, name token.Token, value interface{}) *RuntimeError {
+	env := e.ancestor(distance)
+	if env.constants[name.Lexeme] {
+		return constantAssignment(name)
+	}
+
+	env.values[name.Lexeme] = value
+
+	return nil
+}
+
+func (e *Environment) ancestor(distance int) *Environment {
+	env := e
+	for idx := 0; idx < distance; idx++ {
+		env = env.enclosing
+	}
+
+	return env
+}
+
+func constantAssignment(name token.Token) *RuntimeError {
+	return &RuntimeError{
+		Token: name,
+		Msg:   "Can't assign to constant '" + name.Lexeme + "'.",
+	}
 }
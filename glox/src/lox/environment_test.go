@@ -0,0 +1,29 @@
+package lox
+
+import "testing"
+
+func TestConstants(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "const", source: "const a = 1; print a;", want: "1\n"},
+		{name: "let", source: "let a = 1; a = 2; print a;", want: "2\n2\n"},
+		{name: "const contents stay mutable", source: "const l = [1]; l[0] = 2; print l;", want: "2\n[2]\n"},
+		{name: "local const", source: "{ const a = 1; { var a = 2; a = 3; print a; } print a; }", want: "3\n1\n"},
+		{name: "reassigning a const", source: "const a = 1; a = 2;", err: "Can't assign to constant 'a'."},
+		{name: "reassigning a local const", source: "{ const a = 1; a = 2; }", err: "Can't assign to constant 'a'."},
+		{name: "compound assignment", source: "const a = 1; a += 1;", err: "Can't assign to constant 'a'."},
+		{name: "increment", source: "fun f() { const n = 0; n++; }", err: "Can't assign to constant 'n'."},
+		{
+			name:   "closures",
+			source: "const a = 1; fun f() { a = 2; }",
+			err:    "Can't assign to constant 'a'.",
+		},
+		{
+			name:   "globals checked at runtime",
+			source: "fun f() { c = 2; }\nconst c = 1;\nprint \"before\";\nf();",
+			want:   "before\n",
+			err:    "Can't assign to constant 'c'.\n[line 1]",
+		},
+		{name: "redeclaring a global", source: "const a = 1; var a = 2; a = 3; print a;", want: "3\n3\n"},
+		{name: "const needs a value", source: "const a;", err: "Expect '=' after constant name."},
+	})
+}
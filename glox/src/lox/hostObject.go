@@ -0,0 +1,30 @@
+package lox
+
+import "github.com/dmcg310/glox/src/token"
+
+// HostObject is a value owned by the program embedding the interpreter.
+// Scripts read its properties with obj.name and assign them with
+// obj.name = value, and an error from either is raised as a runtime error
+// at the property name.
+type HostObject interface {
+	GetProperty(name string) (interface{}, error)
+	SetProperty(name string, value interface{}) error
+	String() string
+}
+
+func getProperty(host HostObject, name token.Token) (interface{}, error) {
+	value, err := host.GetProperty(name.Lexeme)
+	if err != nil {
+		return nil, &RuntimeError{Token: name, Msg: err.Error()}
+	}
+
+	return value, nil
+}
+
+func setProperty(host HostObject, name token.Token, value interface{}) error {
+	if err := host.SetProperty(name.Lexeme, value); err != nil {
+		return &RuntimeError{Token: name, Msg: err.Error()}
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package lox
+
+import "github.com/dmcg310/glox/src/token"
+
+func checkIndex(bracket token.Token, index interface{}, length int) (int, error) {
+	num, ok := toInteger(index)
+	if !ok {
+		return 0, &RuntimeError{Token: bracket, Msg: "Index must be an integer."}
+	}
+
+	if num < 0 {
+		return 0, &RuntimeError{Token: bracket, Msg: "Index can't be negative."}
+	}
+
+	if num >= int64(length) {
+		return 0, &RuntimeError{Token: bracket, Msg: "Index out of range."}
+	}
+
+	return int(num), nil
+}
+
+// indexString returns the code point at index as a one character string.
+func indexString(bracket token.Token, str string, index interface{}) (interface{}, error) {
+	runes := []rune(str)
+	idx, err := checkIndex(bracket, index, len(runes))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(runes[idx]), nil
+}
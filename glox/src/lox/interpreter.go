@@ -1,35 +1,247 @@
 package lox
 
 import (
+	"context"
 	"fmt"
 	"github.com/dmcg310/glox/src/ast"
+	"github.com/dmcg310/glox/src/decimal"
 	"github.com/dmcg310/glox/src/token"
+	"io"
+	"math/big"
+	"os"
 	"strconv"
+	"strings"
 )
 
 type Interpreter struct {
-	Environment Environment
+	Globals     *Environment
+	Environment *Environment
+	Importer    Importer
+	Stdout      io.Writer
+	Limits      Limits
+	builtins    *Environment
+	locals      map[ast.Expr]int
+	errorClass  *LoxClass
+
+	ctx   context.Context
+	steps int64
+	depth int
+
+	// site is the innermost loop or call being run, where a limit hit by a
+	// statement in a block is reported
+	site token.Token
+
+	// starts holds where each top-level statement starts, which is the site
+	// while it runs outside any loop or call
+	starts map[ast.Stmt]token.Token
 }
 
-func (i *Interpreter) interpret(statements []ast.Stmt, environment Environment) error {
-	i.Environment = environment
+func NewInterpreter() Interpreter {
+	builtins := NewEnvironment()
+	defineNatives(builtins)
+
+	globals := NewEnvironment(builtins)
 
+	return Interpreter{
+		Globals:     globals,
+		Environment: globals,
+		builtins:    builtins,
+		locals:      make(map[ast.Expr]int),
+		errorClass:  NewLoxClass("RuntimeError", nil, make(map[string]*LoxFunction)),
+		Limits:      Limits{MaxCallDepth: DEFAULT_MAX_CALL_DEPTH},
+		starts:      make(map[ast.Stmt]token.Token),
+	}
+}
+
+// interpret runs top-level statements and returns the value of the last one
+// if it was an expression statement. With echo set, the value of every
+// top-level expression statement is printed as well.
+func (i *Interpreter) interpret(statements []ast.Stmt, echo bool) (interface{}, error) {
+	var result interface{}
 	for _, stmt := range statements {
-		if expressionStmt, ok := stmt.(*ast.Expression); ok {
-			result, err := i.evaluate(expressionStmt.Expression)
-			if err != nil {
-				return err
-			}
+		value, err := i.interpretStatement(stmt, echo)
+		if err != nil {
+			return nil, err
+		}
 
-			fmt.Println(i.stringify(result))
-		} else {
-			_, err := stmt.Accept(i)
-			if err != nil {
-				return err
-			}
+		result = value
+	}
+
+	return result, nil
+}
+
+func (i *Interpreter) interpretStatement(stmt ast.Stmt, echo bool) (interface{}, error) {
+	site := i.site
+	i.site = i.statementSite(stmt)
+	defer func() {
+		i.site = site
+	}()
+
+	if err := i.step(i.site); err != nil {
+		return nil, err
+	}
+
+	expressionStmt, ok := stmt.(*ast.Expression)
+	if !ok {
+		_, err := stmt.Accept(i)
+
+		return nil, err
+	}
+
+	value, err := i.evaluate(expressionStmt.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if echo {
+		fmt.Fprintln(i.stdout(), stringify(value))
+	}
+
+	return value, nil
+}
+
+// markStart records where a top-level statement starts.
+func (i *Interpreter) markStart(stmt ast.Stmt, start token.Token) {
+	i.starts[stmt] = start
+}
+
+// statementSite is where a limit hit while running stmt is reported: where
+// it starts if it is a top-level statement, and the innermost loop or call
+// otherwise.
+func (i *Interpreter) statementSite(stmt ast.Stmt) token.Token {
+	if start, ok := i.starts[stmt]; ok {
+		return start
+	}
+
+	return i.site
+}
+
+func (i *Interpreter) VisitLambda(expr *ast.Lambda) (interface{}, error) {
+	return NewLoxFunction(expr.Function, i.Environment, i.Globals, false), nil
+}
+
+func (i *Interpreter) VisitListLiteral(expr *ast.ListLiteral) (interface{}, error) {
+	elements := []interface{}{}
+	for _, element := range expr.Elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
 		}
+
+		elements = append(elements, value)
 	}
-	return nil
+
+	return NewLoxList(elements), nil
+}
+
+func (i *Interpreter) VisitInterpolation(expr *ast.Interpolation) (interface{}, error) {
+	var builder strings.Builder
+	for _, part := range expr.Parts {
+		value, err := i.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+
+		builder.WriteString(stringify(value))
+	}
+
+	return builder.String(), nil
+}
+
+func (i *Interpreter) VisitMapLiteral(expr *ast.MapLiteral) (interface{}, error) {
+	m := NewLoxMap()
+	for idx, keyExpr := range expr.Keys {
+		key, err := i.evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := i.evaluate(expr.Values[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		m.set(key, value)
+	}
+
+	return m, nil
+}
+
+func (i *Interpreter) VisitIndex(expr *ast.Index) (interface{}, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.getIndex(expr.Bracket, object, index)
+}
+
+func (i *Interpreter) VisitIndexSet(expr *ast.IndexSet) (interface{}, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.setIndex(expr.Bracket, object, index, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (i *Interpreter) getIndex(bracket token.Token, object, index interface{}) (interface{}, error) {
+	switch collection := object.(type) {
+	case *LoxList:
+		return collection.get(bracket, index)
+	case *LoxMap:
+		return collection.get(bracket, index)
+	case string:
+		return indexString(bracket, collection, index)
+	}
+
+	return nil, &RuntimeError{Token: bracket, Msg: "Only lists, maps and strings can be indexed."}
+}
+
+func (i *Interpreter) setIndex(bracket token.Token, object, index, value interface{}) error {
+	switch collection := object.(type) {
+	case *LoxList:
+		return collection.set(bracket, index, value)
+	case *LoxMap:
+		collection.set(index, value)
+
+		return nil
+	case string:
+		return &RuntimeError{Token: bracket, Msg: "Strings are immutable."}
+	}
+
+	return &RuntimeError{Token: bracket, Msg: "Only lists and maps can be assigned by index."}
+}
+
+// executeModule runs an imported file's statements with globals standing in
+// for the interpreter's own until it finishes.
+func (i *Interpreter) executeModule(statements []ast.Stmt, globals *Environment) error {
+	prevGlobals := i.Globals
+	i.Globals = globals
+	defer func() {
+		i.Globals = prevGlobals
+	}()
+
+	return i.executeBlock(statements, globals)
 }
 
 func (i *Interpreter) VisitLiteral(expr *ast.Literal) (interface{}, error) {
@@ -67,87 +279,311 @@ func (i *Interpreter) execute(stmt ast.Stmt) (interface{}, error) {
 	return stmt.Accept(i)
 }
 
-func (i *Interpreter) VisitBlock(stmt *ast.Block) error {
-	err := i.executeBlock(stmt.Statements, NewEnvironment(&i.Environment))
+func (i *Interpreter) VisitBlock(stmt *ast.Block) (interface{}, error) {
+	err := i.executeBlock(stmt.Statements, NewEnvironment(i.Environment))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (i *Interpreter) executeBlock(statements []ast.Stmt, environment Environment) error {
+func (i *Interpreter) executeBlock(statements []ast.Stmt, environment *Environment) error {
 	prev := i.Environment
 	i.Environment = environment
 
+	// a return unwinds through here as an error, so the previous
+	// environment has to be restored on every exit path
+	defer func() {
+		i.Environment = prev
+	}()
+
 	for _, statement := range statements {
 		if statement == nil {
 			continue
 		}
 
-		_, err := i.execute(statement)
+		// a module's top-level statements run here rather than in interpret
+		site := i.site
+		i.site = i.statementSite(statement)
+		err := i.step(i.site)
+		if err == nil {
+			_, err = i.execute(statement)
+		}
+
+		i.site = site
 		if err != nil {
 			return err
 		}
 	}
 
-	i.Environment = prev
-
 	return nil
 }
 
-func (i *Interpreter) VisitExpression(stmt *ast.Expression) error {
+func (i *Interpreter) VisitBreak(stmt *ast.Break) (interface{}, error) {
+	return nil, &Break{}
+}
+
+func (i *Interpreter) VisitContinue(stmt *ast.Continue) (interface{}, error) {
+	return nil, &Continue{}
+}
+
+func (i *Interpreter) VisitClass(stmt *ast.Class) (interface{}, error) {
+	var superclass *LoxClass
+	if stmt.Superclass != nil {
+		value, err := i.evaluate(stmt.Superclass)
+		if err != nil {
+			return nil, err
+		}
+
+		class, ok := value.(*LoxClass)
+		if !ok {
+			return nil, &RuntimeError{Token: stmt.Superclass.Name, Msg: "Superclass must be a class."}
+		}
+
+		superclass = class
+	}
+
+	i.Environment.define(stmt.Name.Lexeme, nil)
+
+	if superclass != nil {
+		i.Environment = NewEnvironment(i.Environment)
+		i.Environment.define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction)
+	for _, method := range stmt.Methods {
+		function := NewLoxFunction(method, i.Environment, i.Globals, method.Name.Lexeme == "init")
+		methods[method.Name.Lexeme] = function
+	}
+
+	class := NewLoxClass(stmt.Name.Lexeme, superclass, methods)
+
+	if superclass != nil {
+		i.Environment = i.Environment.enclosing
+	}
+
+	if err := i.Environment.assign(stmt.Name, class); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (i *Interpreter) VisitExpression(stmt *ast.Expression) (interface{}, error) {
 	_, err := i.evaluate(stmt.Expression)
 
-	return err
+	return nil, err
 }
 
-func (i *Interpreter) VisitIf(stmt *ast.If) error {
+func (i *Interpreter) VisitFunction(stmt *ast.Function) (interface{}, error) {
+	function := NewLoxFunction(stmt, i.Environment, i.Globals, false)
+	i.Environment.define(stmt.Name.Lexeme, function)
+
+	return nil, nil
+}
+
+func (i *Interpreter) VisitIf(stmt *ast.If) (interface{}, error) {
 	res, err := i.evaluate(stmt.Condition)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if i.isTruthy(res) {
-		i.execute(stmt.ThenBranch)
+		return i.execute(stmt.ThenBranch)
 	} else if stmt.ElseBranch != nil {
-		i.execute(stmt.ElseBranch)
+		return i.execute(stmt.ElseBranch)
 	}
 
-	return nil
+	return nil, nil
+}
+
+func (i *Interpreter) VisitImport(stmt *ast.Import) (interface{}, error) {
+	if i.Importer == nil {
+		return nil, &RuntimeError{Token: stmt.Keyword, Msg: "Imports are not supported here."}
+	}
+
+	module, err := i.Importer.Import(stmt.Keyword, stmt.Path.Literal.(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.Names == nil {
+		i.Environment.define(stmt.Alias.Lexeme, module)
+		return nil, nil
+	}
+
+	for _, name := range stmt.Names {
+		value, err := module.get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		i.Environment.define(name.Lexeme, value)
+	}
+
+	return nil, nil
 }
 
-func (i *Interpreter) VisitPrint(stmt *ast.Print) error {
+func (i *Interpreter) VisitPrint(stmt *ast.Print) (interface{}, error) {
 	value, err := i.evaluate(stmt.Expression)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Println(i.stringify(value))
+	fmt.Fprintln(i.stdout(), stringify(value))
 
-	return nil
+	return nil, nil
 }
 
-func (i *Interpreter) VisitVariable(expr *ast.Variable) (interface{}, error) {
-	res, err := i.Environment.get(expr.Name)
+// stdout is where print writes, which is standard output unless Stdout is
+// set.
+func (i *Interpreter) stdout() io.Writer {
+	if i.Stdout == nil {
+		return os.Stdout
+	}
+
+	return i.Stdout
+}
+
+func (i *Interpreter) VisitReturn(stmt *ast.Return) (interface{}, error) {
+	var value interface{}
+	var err error
+
+	if stmt.Value != nil {
+		value, err = i.evaluate(stmt.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, &Return{Value: value}
+}
+
+func (i *Interpreter) VisitThrow(stmt *ast.Throw) (interface{}, error) {
+	value, err := i.evaluate(stmt.Value)
 	if err != nil {
 		return nil, err
 	}
 
-	return res, nil
+	return nil, &Throw{Keyword: stmt.Keyword, Value: value}
 }
 
-func (i *Interpreter) VisitWhile(stmt *ast.While) (interface{}, error) {
-	res, err := i.evaluate(stmt.Condition)
+func (i *Interpreter) VisitTry(stmt *ast.Try) (interface{}, error) {
+	err := i.executeBlock(stmt.Body.Statements, NewEnvironment(i.Environment))
+
+	if err != nil && stmt.CatchBody != nil {
+		if exception, ok := i.exceptionValue(err); ok {
+			environment := NewEnvironment(i.Environment)
+			environment.define(stmt.CatchName.Lexeme, exception)
+
+			err = i.executeBlock(stmt.CatchBody.Statements, environment)
+		}
+	}
+
+	// finally runs however the try and catch blocks were left, and anything
+	// it raises itself replaces what was already unwinding
+	if stmt.FinallyBody != nil {
+		finallyErr := i.executeBlock(stmt.FinallyBody.Statements, NewEnvironment(i.Environment))
+		if finallyErr != nil {
+			return nil, finallyErr
+		}
+	}
+
+	return nil, err
+}
+
+// exceptionValue returns the Lox value a catch clause binds for err. Return,
+// break and continue also travel as errors but can't be caught, and nor can
+// some limits.
+func (i *Interpreter) exceptionValue(err error) (interface{}, bool) {
+	switch exception := err.(type) {
+	case *Throw:
+		return exception.Value, true
+	case *RuntimeError:
+		instance := NewLoxInstance(i.errorClass)
+		instance.fields["message"] = exception.Msg
+		instance.fields["line"] = int64(exception.Token.Line)
+
+		return instance, true
+	case *LimitError:
+		if !exception.catchable() {
+			return nil, false
+		}
+
+		instance := NewLoxInstance(i.errorClass)
+		instance.fields["message"] = exception.Error()
+		instance.fields["line"] = int64(exception.Token.Line)
+
+		return instance, true
+	}
+
+	return nil, false
+}
+
+func (i *Interpreter) VisitVariable(expr *ast.Variable) (interface{}, error) {
+	return i.lookUpVariable(expr.Name, expr)
+}
+
+func (i *Interpreter) lookUpVariable(name token.Token, expr ast.Expr) (interface{}, error) {
+	if distance, ok := i.locals[expr]; ok {
+		return i.Environment.getAt(distance, name.Lexeme), nil
+	}
+
+	val, err := i.Globals.get(name)
 	if err != nil {
 		return nil, err
 	}
 
-	for i.isTruthy(res) {
-		_, err := i.execute(stmt.Body)
+	return val, nil
+}
+
+func (i *Interpreter) resolve(expr ast.Expr, depth int) {
+	i.locals[expr] = depth
+}
+
+func (i *Interpreter) VisitWhile(stmt *ast.While) (interface{}, error) {
+	site := i.site
+	i.site = stmt.Keyword
+	defer func() {
+		i.site = site
+	}()
+
+	for iterations := int64(1); ; iterations++ {
+		if err := i.step(stmt.Keyword); err != nil {
+			return nil, err
+		}
+
+		res, err := i.evaluate(stmt.Condition)
 		if err != nil {
 			return nil, err
 		}
+
+		if !i.isTruthy(res) {
+			break
+		}
+
+		if i.Limits.MaxLoopIterations > 0 && iterations > i.Limits.MaxLoopIterations {
+			return nil, &LimitError{Token: stmt.Keyword, Limit: LOOP_LIMIT}
+		}
+
+		_, err = i.execute(stmt.Body)
+		if err != nil {
+			if _, ok := err.(*Break); ok {
+				break
+			}
+
+			if _, ok := err.(*Continue); !ok {
+				return nil, err
+			}
+		}
+
+		if stmt.Increment != nil {
+			_, err = i.evaluate(stmt.Increment)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return nil, nil
@@ -164,11 +600,182 @@ func (i *Interpreter) VisitAssign(expr *ast.Assign) (interface{}, error) {
 		return nil, err
 	}
 
-	_ = i.Environment.assign(expr.Name, val)
+	if err := i.assignVariable(expr.Name, expr, val); err != nil {
+		return nil, err
+	}
 
 	return val, nil
 }
 
+func (i *Interpreter) assignVariable(name token.Token, expr ast.Expr, value interface{}) error {
+	if distance, ok := i.locals[expr]; ok {
+		if err := i.Environment.assignAt(distance, name, value); err != nil {
+			return err
+		}
+	} else if err := i.Globals.assign(name, value); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (i *Interpreter) VisitCompoundAssign(expr *ast.CompoundAssign) (interface{}, error) {
+	get, set, err := i.reference(expr.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := get()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := i.binary(compoundOperator(expr.Operator), current, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := set(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (i *Interpreter) VisitIncrement(expr *ast.Increment) (interface{}, error) {
+	get, set, err := i.reference(expr.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := get()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.checkNumberOperand(expr.Operator, current); err != nil {
+		return nil, err
+	}
+
+	operator := expr.Operator
+	operator.Type = token.PLUS
+	if expr.Operator.Type == token.MINUS_MINUS {
+		operator.Type = token.MINUS
+	}
+
+	result, err := arithmetic(operator, current, int64(1))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := set(result); err != nil {
+		return nil, err
+	}
+
+	if expr.Prefix {
+		return result, nil
+	}
+
+	return current, nil
+}
+
+// reference evaluates the parts of an assignable target exactly once and
+// returns accessors for reading and writing the location it names. Every
+// read-modify-write operator goes through here, so a new kind of assignable
+// target only needs a case adding below.
+func (i *Interpreter) reference(target ast.Expr) (func() (interface{}, error), func(interface{}) error, error) {
+	switch target := target.(type) {
+	case *ast.Variable:
+		get := func() (interface{}, error) {
+			return i.lookUpVariable(target.Name, target)
+		}
+		set := func(value interface{}) error {
+			return i.assignVariable(target.Name, target, value)
+		}
+
+		return get, set, nil
+	case *ast.Get:
+		object, err := i.evaluate(target.Object)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if host, ok := object.(HostObject); ok {
+			get := func() (interface{}, error) {
+				return getProperty(host, target.Name)
+			}
+			set := func(value interface{}) error {
+				return setProperty(host, target.Name, value)
+			}
+
+			return get, set, nil
+		}
+
+		instance, ok := object.(*LoxInstance)
+		if !ok {
+			return nil, nil, &RuntimeError{Token: target.Name, Msg: "Only instances have fields."}
+		}
+
+		get := func() (interface{}, error) {
+			return instance.get(target.Name)
+		}
+		set := func(value interface{}) error {
+			instance.set(target.Name, value)
+			return nil
+		}
+
+		return get, set, nil
+	case *ast.Index:
+		object, err := i.evaluate(target.Object)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		index, err := i.evaluate(target.Index)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		get := func() (interface{}, error) {
+			return i.getIndex(target.Bracket, object, index)
+		}
+		set := func(value interface{}) error {
+			return i.setIndex(target.Bracket, object, index, value)
+		}
+
+		return get, set, nil
+	}
+
+	return nil, nil, fmt.Errorf("invalid assignment target: %T", target)
+}
+
+// compoundOperator maps a compound assignment token such as "+=" onto the
+// binary operator it applies.
+func compoundOperator(operator token.Token) token.Token {
+	binary := operator
+	binary.Lexeme = strings.TrimSuffix(operator.Lexeme, "=")
+
+	switch operator.Type {
+	case token.PLUS_EQUAL:
+		binary.Type = token.PLUS
+	case token.MINUS_EQUAL:
+		binary.Type = token.MINUS
+	case token.STAR_EQUAL:
+		binary.Type = token.STAR
+	case token.SLASH_EQUAL:
+		binary.Type = token.SLASH
+	case token.PERCENT_EQUAL:
+		binary.Type = token.PERCENT
+	}
+
+	return binary
+}
+
 func (i *Interpreter) VisitVar(stmt *ast.Var) (interface{}, error) {
 	var val interface{}
 	var err error
@@ -180,7 +787,11 @@ func (i *Interpreter) VisitVar(stmt *ast.Var) (interface{}, error) {
 		}
 	}
 
-	i.Environment.define(stmt.Name.Lexeme, val)
+	if stmt.Constant {
+		i.Environment.defineConstant(stmt.Name.Lexeme, val)
+	} else {
+		i.Environment.define(stmt.Name.Lexeme, val)
+	}
 
 	return nil, nil
 }
@@ -196,51 +807,26 @@ func (i *Interpreter) VisitBinary(expr *ast.Binary) (interface{}, error) {
 		return nil, err
 	}
 
-	leftNum, leftIsNum := left.(float64)
-	rightNum, rightIsNum := right.(float64)
-	if !leftIsNum || !rightIsNum {
-		return nil, fmt.Errorf("operands must be numbers")
-	}
-
-	switch expr.Operator.Type {
-	case token.GREATER:
-		err := i.checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-
-		return leftNum > rightNum, nil
-	case token.GREATER_EQUAL:
-		err := i.checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-
-		return leftNum >= rightNum, nil
-	case token.LESS:
-		err := i.checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
+	return i.binary(expr.Operator, left, right)
+}
 
-		return leftNum < rightNum, nil
-	case token.LESS_EQUAL:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+func (i *Interpreter) binary(operator token.Token, left, right interface{}) (interface{}, error) {
+	switch operator.Type {
+	case token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL:
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 
-		return leftNum <= rightNum, nil
+		return compareNumbers(operator, left, right), nil
 	case token.BANG_EQUAL:
 		return !i.isEqual(left, right), nil
 	case token.EQUAL_EQUAL:
 		return i.isEqual(left, right), nil
 	case token.PLUS:
 		// handle addition for numbers
-		leftNum, leftIsNum := left.(float64)
-		rightNum, rightIsNum := right.(float64)
-		if leftIsNum && rightIsNum {
-			return leftNum + rightNum, nil
+		if isNumber(left) && isNumber(right) {
+			return arithmetic(operator, left, right)
 		}
 
 		// handle concatenation for strings
@@ -250,61 +836,162 @@ func (i *Interpreter) VisitBinary(expr *ast.Binary) (interface{}, error) {
 			return leftStr + rightStr, nil
 		}
 
-		return nil, &RuntimeError{Token: expr.Operator, Msg: "Operands must be two numbers or two strings."}
-	case token.MINUS:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+		return nil, &RuntimeError{Token: operator, Msg: "Operands must be two numbers or two strings."}
+	case token.MINUS, token.SLASH, token.STAR, token.PERCENT, token.STAR_STAR:
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 
-		leftVal, ok := left.(float64)
-		if !ok {
-			return nil, fmt.Errorf("left operand must be a number")
-		}
-
-		rightVal, ok := right.(float64)
-		if !ok {
-			return nil, fmt.Errorf("right operand must be a number")
-		}
-
-		return leftVal - rightVal, nil
-	case token.SLASH:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+		return arithmetic(operator, left, right)
+	case token.AMPERSAND, token.PIPE, token.CARET, token.LESS_LESS, token.GREATER_GREATER:
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 
-		leftVal, ok := left.(float64)
-		if !ok {
-			return nil, fmt.Errorf("left operand must be a number")
-		}
+		return bitwise(operator, left, right)
+	}
 
-		rightVal, ok := right.(float64)
-		if !ok {
-			return nil, fmt.Errorf("right operand must be a number")
-		}
+	return nil, fmt.Errorf("unknown binary operator: %v", operator.Type)
+}
 
-		return leftVal / rightVal, nil
-	case token.STAR:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+func (i *Interpreter) VisitCall(expr *ast.Call) (interface{}, error) {
+	callee, err := i.evaluate(expr.Callee)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := []interface{}{}
+	for _, argument := range expr.Arguments {
+		arg, err := i.evaluate(argument)
 		if err != nil {
 			return nil, err
 		}
 
-		leftVal, ok := left.(float64)
-		if !ok {
-			return nil, fmt.Errorf("left operand must be a number")
+		arguments = append(arguments, arg)
+	}
+
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		return nil, &RuntimeError{Token: expr.Paren, Msg: "Can only call functions and classes."}
+	}
+
+	if msg := arityMismatch(function, len(arguments)); msg != "" {
+		return nil, &RuntimeError{Token: expr.Paren, Msg: msg}
+	}
+
+	if err := i.step(expr.Paren); err != nil {
+		return nil, err
+	}
+
+	if i.Limits.MaxCallDepth > 0 && i.depth >= i.Limits.MaxCallDepth {
+		return nil, &LimitError{Token: expr.Paren, Limit: CALL_DEPTH_LIMIT}
+	}
+
+	site := i.site
+	i.site = expr.Paren
+	i.depth++
+	defer func() {
+		i.site = site
+		i.depth--
+	}()
+
+	result, err := function.Call(i, arguments)
+	if _, isNative := function.(*NativeFunction); isNative && err != nil {
+		// natives don't know where they were called from, so errors are
+		// attached to the call site here
+		switch err.(type) {
+		case *RuntimeError, *LimitError:
+		default:
+			return nil, &RuntimeError{Token: expr.Paren, Msg: err.Error()}
 		}
+	}
 
-		rightVal, ok := right.(float64)
-		if !ok {
-			return nil, fmt.Errorf("right operand must be a number")
+	return result, err
+}
+
+func (i *Interpreter) VisitGet(expr *ast.Get) (interface{}, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := object.(type) {
+	case *LoxInstance:
+		return value.get(expr.Name)
+	case *LoxModule:
+		return value.get(expr.Name)
+	case HostObject:
+		return getProperty(value, expr.Name)
+	}
+
+	return nil, &RuntimeError{Token: expr.Name, Msg: "Only instances and modules have properties."}
+}
+
+func (i *Interpreter) VisitSet(expr *ast.Set) (interface{}, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, isModule := object.(*LoxModule); isModule {
+		return nil, &RuntimeError{Token: expr.Name, Msg: "Can't assign to module members."}
+	}
+
+	host, isHost := object.(HostObject)
+	instance, ok := object.(*LoxInstance)
+	if !ok && !isHost {
+		return nil, &RuntimeError{Token: expr.Name, Msg: "Only instances have fields."}
+	}
+
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if isHost {
+		return value, setProperty(host, expr.Name, value)
+	}
+
+	instance.set(expr.Name, value)
+
+	return value, nil
+}
+
+func (i *Interpreter) VisitSuper(expr *ast.Super) (interface{}, error) {
+	distance := i.locals[expr]
+	superclass := i.Environment.getAt(distance, "super").(*LoxClass)
+
+	// "this" is always bound one environment inside the one holding "super"
+	object := i.Environment.getAt(distance-1, "this").(*LoxInstance)
+
+	method := superclass.findMethod(expr.Method.Lexeme)
+	if method == nil {
+		return nil, &RuntimeError{
+			Token: expr.Method,
+			Msg:   "Undefined property '" + expr.Method.Lexeme + "'.",
 		}
+	}
+
+	return method.bind(object), nil
+}
+
+func (i *Interpreter) VisitThis(expr *ast.This) (interface{}, error) {
+	return i.lookUpVariable(expr.Keyword, expr)
+}
+
+func (i *Interpreter) VisitTernary(expr *ast.Ternary) (interface{}, error) {
+	condition, err := i.evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
 
-		return leftVal * rightVal, nil
+	if i.isTruthy(condition) {
+		return i.evaluate(expr.ThenBranch)
 	}
 
-	return nil, fmt.Errorf("unknown binary operator: %v", expr.Operator.Type)
+	return i.evaluate(expr.ElseBranch)
 }
 
 func (i *Interpreter) VisitUnary(expr *ast.Unary) (interface{}, error) {
@@ -320,12 +1007,14 @@ func (i *Interpreter) VisitUnary(expr *ast.Unary) (interface{}, error) {
 			return nil, err
 		}
 
-		rightVal, ok := right.(float64)
-		if !ok {
-			return nil, fmt.Errorf("operand must be a number")
+		return negate(right), nil
+	case token.TILDE:
+		err := i.checkNumberOperand(expr.Operator, right)
+		if err != nil {
+			return nil, err
 		}
 
-		return -rightVal, nil
+		return complement(right), nil
 	case token.BANG:
 		return !i.isTruthy(right), nil
 	}
@@ -334,8 +1023,7 @@ func (i *Interpreter) VisitUnary(expr *ast.Unary) (interface{}, error) {
 }
 
 func (i *Interpreter) checkNumberOperand(operator token.Token, operand interface{}) error {
-	_, ok := operand.(float64)
-	if !ok {
+	if !isNumber(operand) {
 		return &RuntimeError{Token: operator, Msg: "Operand must be a number."}
 	}
 
@@ -343,9 +1031,7 @@ func (i *Interpreter) checkNumberOperand(operator token.Token, operand interface
 }
 
 func (i *Interpreter) checkNumberOperands(operator token.Token, left, right interface{}) error {
-	_, leftOk := left.(float64)
-	_, rightOk := right.(float64)
-	if leftOk && rightOk {
+	if isNumber(left) && isNumber(right) {
 		return nil
 	}
 
@@ -374,21 +1060,100 @@ func (i *Interpreter) isEqual(a, b interface{}) bool {
 		return false
 	}
 
+	// numbers of different kinds holding the same value are equal
+	if isNumber(a) && isNumber(b) {
+		return compare(a, b) == 0
+	}
+
 	return a == b
 }
 
-func (i *Interpreter) stringify(obj interface{}) string {
+func stringify(obj interface{}) string {
+	return stringifyNested(obj, nil)
+}
+
+// stringifyNested is stringify with printing holding the collections that
+// enclose obj, so that a collection which contains itself prints a
+// placeholder where it repeats instead of recursing forever.
+func stringifyNested(obj interface{}, printing map[interface{}]bool) string {
 	if obj == nil {
 		return "nil"
 	}
 
+	if num, ok := obj.(int64); ok {
+		return strconv.FormatInt(num, 10)
+	}
+
 	if num, ok := obj.(float64); ok {
 		return strconv.FormatFloat(num, 'f', -1, 64)
 	}
 
+	if num, ok := obj.(*big.Int); ok {
+		return num.String()
+	}
+
+	if num, ok := obj.(*decimal.Decimal); ok {
+		return num.String()
+	}
+
 	if str, ok := obj.(string); ok {
 		return str
 	}
 
+	if list, ok := obj.(*LoxList); ok {
+		if printing[list] {
+			return "[...]"
+		}
+
+		if printing == nil {
+			printing = make(map[interface{}]bool)
+		}
+
+		printing[list] = true
+		defer delete(printing, list)
+
+		elements := make([]string, len(list.Elements))
+		for idx, element := range list.Elements {
+			elements[idx] = stringifyElement(element, printing)
+		}
+
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+
+	if m, ok := obj.(*LoxMap); ok {
+		if printing[m] {
+			return "{...}"
+		}
+
+		if printing == nil {
+			printing = make(map[interface{}]bool)
+		}
+
+		printing[m] = true
+		defer delete(printing, m)
+
+		keys, values := m.Keys(), m.Values()
+		entries := make([]string, len(keys))
+		for idx, key := range keys {
+			entries[idx] = stringifyElement(key, printing) + ": " + stringifyElement(values[idx], printing)
+		}
+
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+
+	if host, ok := obj.(HostObject); ok {
+		return host.String()
+	}
+
 	return fmt.Sprintf("%v", obj)
 }
+
+func stringifyElement(obj interface{}, printing map[interface{}]bool) string {
+	// strings nested inside a collection keep their quotes so that
+	// ["a, b"] and ["a", "b"] print differently
+	if str, ok := obj.(string); ok {
+		return "\"" + str + "\""
+	}
+
+	return stringifyNested(obj, printing)
+}
@@ -0,0 +1,102 @@
+package lox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dmcg310/glox/src/token"
+)
+
+// DEFAULT_MAX_CALL_DEPTH is the call depth NewInterpreter allows. Each Lox
+// call takes several Go frames, and Go can't recover from running out of
+// stack, so unbounded recursion has to be stopped well before that.
+const DEFAULT_MAX_CALL_DEPTH = 10000
+
+// Limits bounds the work a script can do, so that a runaway script is
+// stopped instead of hanging whatever is running it. A zero field means no
+// limit.
+type Limits struct {
+	// MaxSteps caps the statements executed, loop iterations started and
+	// calls made in one run.
+	MaxSteps int64
+
+	// MaxCallDepth is DEFAULT_MAX_CALL_DEPTH in a new interpreter, and
+	// leaving it at zero risks crashing the process on deep recursion.
+	MaxCallDepth int
+
+	// MaxLoopIterations caps the iterations of any one loop each time it
+	// runs.
+	MaxLoopIterations int64
+}
+
+type Limit int
+
+const (
+	STEP_LIMIT Limit = iota
+	CALL_DEPTH_LIMIT
+	LOOP_LIMIT
+	CANCELLED
+)
+
+// LimitError stops a script that went past one of the interpreter's Limits
+// or whose context was cancelled, in which case Err is the context's error.
+// Scripts can catch the call depth and loop limits, but running out of
+// steps or being cancelled unwinds through every try.
+type LimitError struct {
+	Token token.Token
+	Limit Limit
+	Err   error
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case STEP_LIMIT:
+		return "Step limit exceeded."
+	case CALL_DEPTH_LIMIT:
+		return "Maximum call depth exceeded."
+	case LOOP_LIMIT:
+		return "Loop iteration limit exceeded."
+	}
+
+	return fmt.Sprintf("Execution cancelled: %s.", e.Err)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *LimitError) catchable() bool {
+	return e.Limit == CALL_DEPTH_LIMIT || e.Limit == LOOP_LIMIT
+}
+
+// begin starts a run on behalf of the host, with a fresh step budget. A run
+// nested inside a native function is part of the run that called the
+// native, so it keeps that run's budget and context.
+func (i *Interpreter) begin(ctx context.Context) func() {
+	if i.depth > 0 {
+		return func() {}
+	}
+
+	i.ctx, i.steps = ctx, 0
+
+	return func() {
+		i.ctx = nil
+	}
+}
+
+// step counts one unit of work against the step budget, and stops the run
+// if its context is done. site is where the error is reported.
+func (i *Interpreter) step(site token.Token) error {
+	i.steps++
+	if i.Limits.MaxSteps > 0 && i.steps > i.Limits.MaxSteps {
+		return &LimitError{Token: site, Limit: STEP_LIMIT}
+	}
+
+	if i.ctx != nil {
+		if err := i.ctx.Err(); err != nil {
+			return &LimitError{Token: site, Limit: CANCELLED, Err: err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package lox
+
+// Break and Continue unwind the interpreter out of the current loop body in
+// the same way Return unwinds out of a function call.
+type Break struct{}
+
+func (b *Break) Error() string {
+	return "break outside of loop"
+}
+
+type Continue struct{}
+
+func (c *Continue) Error() string {
+	return "continue outside of loop"
+}
@@ -0,0 +1,48 @@
+package lox
+
+import "testing"
+
+func TestLoopControl(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name:   "break",
+			source: "var i = 0; while (true) { if (i == 3) break; i = i + 1; } print i;",
+			want:   "3\n",
+		},
+		{
+			name:   "continue in while",
+			source: "var i = 0; var odd = 0; while (i < 6) { i = i + 1; if (i == 2 or i == 4 or i == 6) continue; odd = odd + i; } print odd;",
+			want:   "9\n",
+		},
+		{
+			name:   "continue runs the for increment",
+			source: "for (var i = 0; i < 4; i = i + 1) { if (i == 1) continue; print i; }",
+			want:   "0\n2\n3\n",
+		},
+		{
+			name:   "break only leaves the innermost loop",
+			source: "for (var i = 0; i < 2; i = i + 1) { for (var j = 0; j < 5; j = j + 1) { if (j == 1) break; print j; } print i; }",
+			want:   "0\n0\n0\n1\n",
+		},
+		{
+			name:   "break out of a function's loop",
+			source: "fun f() { while (true) { break; } return 1; } print f();",
+			want:   "1\n",
+		},
+		{
+			name:   "break outside a loop",
+			source: "break;",
+			err:    "Can't use 'break' outside of a loop.",
+		},
+		{
+			name:   "continue outside a loop",
+			source: "if (true) continue;",
+			err:    "Can't use 'continue' outside of a loop.",
+		},
+		{
+			name:   "loops don't reach into functions",
+			source: "while (true) { fun f() { break; } }",
+			err:    "Can't use 'break' outside of a loop.",
+		},
+	})
+}
@@ -2,11 +2,14 @@ package lox
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"github.com/dmcg310/glox/src/report"
 	"github.com/dmcg310/glox/src/scanner"
-	"log"
+	"github.com/dmcg310/glox/src/token"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -14,31 +17,48 @@ type Lox struct {
 	Interpreter     Interpreter
 	HadError        bool
 	HadRuntimeError bool
-	Reporter        report.Reporter
-	Environment     Environment
-}
+	SearchPath      []string
+
+	// Reporter receives compile errors and warnings. If it isn't set they
+	// are written to Stderr along with runtime errors.
+	Reporter report.Reporter
 
-func (l *Lox) RunFile(path string) {
-	l.Environment = NewEnvironment()
+	// Stdin, Stdout and Stderr replace the process's standard streams when
+	// set: the prompt reads from Stdin, print and the prompt write to Stdout
+	// and errors are written to Stderr.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	modules map[string]*LoxModule
+	loading []string
+}
 
+// RunFile runs the script at path. Problems in the script itself are
+// reported and recorded in HadError and HadRuntimeError, so the error
+// returned is only for a file that couldn't be read.
+func (l *Lox) RunFile(path string) error {
 	bytes, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Error reading file: %s", err)
+		return fmt.Errorf("error reading file: %w", err)
 	}
 
-	l.Run(string(bytes))
-	if l.HadError {
-		os.Exit(65)
+	// imports in the script are resolved relative to it
+	if abs, err := filepath.Abs(path); err == nil {
+		l.loading = append(l.loading, abs)
 	}
+
+	l.Run(string(bytes))
+
+	return nil
 }
 
-func (l *Lox) RunPrompt() {
-	l.Environment = NewEnvironment()
-	_scanner := bufio.NewScanner(os.Stdin)
+func (l *Lox) RunPrompt() error {
+	_scanner := bufio.NewScanner(l.stdin())
 
 	for {
 		l.HadError = false
-		fmt.Print("> ")
+		fmt.Fprint(l.stdout(), "> ")
 		if !_scanner.Scan() {
 			break
 		}
@@ -49,36 +69,133 @@ func (l *Lox) RunPrompt() {
 	}
 
 	if err := _scanner.Err(); err != nil {
-		_, e := fmt.Fprintln(os.Stderr, "reading standard input:", err)
-		if e != nil {
-			log.Fatalf("Error printing: %s", e)
-		}
+		return fmt.Errorf("reading standard input: %w", err)
 	}
+
+	return nil
 }
 
+// Run runs source, printing the value of each top-level expression
+// statement and reporting any errors.
 func (l *Lox) Run(source string) {
-	_scanner := scanner.NewScanner(source, l.Reporter)
+	end := l.Interpreter.begin(context.Background())
+	defer end()
+
+	_, err := l.run(source, true)
+	if err != nil && err != ErrCompile {
+		l.runtimeError(err)
+	}
+}
+
+// Eval runs source and returns the value of its last statement, if that
+// was an expression statement. Compile errors go to the Reporter, after
+// which Eval returns ErrCompile; runtime errors are returned rather than
+// reported.
+func (l *Lox) Eval(source string) (interface{}, error) {
+	return l.EvalContext(context.Background(), source)
+}
+
+// EvalContext is Eval for a script that stops with a LimitError once ctx is
+// done.
+func (l *Lox) EvalContext(ctx context.Context, source string) (interface{}, error) {
+	end := l.Interpreter.begin(ctx)
+	defer end()
+
+	return l.run(source, false)
+}
+
+func (l *Lox) run(source string, echo bool) (interface{}, error) {
+	_scanner := scanner.NewScanner(source, l)
 	tokens := _scanner.ScanTokens()
 	parser := NewParser(tokens, l)
-	expr := parser.Parse()
+	statements := parser.Parse()
 
 	if l.HadError {
-		return
+		return nil, ErrCompile
 	}
 
-	if l.HadRuntimeError {
-		os.Exit(70)
+	resolver := NewResolver(&l.Interpreter, l)
+	resolver.resolve(statements)
+
+	if l.HadError {
+		return nil, ErrCompile
 	}
 
-	_ = l.Interpreter.interpret(expr, l.Environment)
+	l.Interpreter.Importer = l
+	l.Interpreter.Stdout = l.stdout()
+
+	return l.Interpreter.interpret(statements, echo)
 }
 
 func (l *Lox) Error(line int, message string) {
-	l.Reporter.Error(line, message)
+	l.reporter().Error(line, message)
 	l.HadError = true
 }
 
-func (l *Lox) runtimeError(error *RuntimeError) {
-	log.Printf("%s\n[line %d]\n", error.Msg, error.Token.Line)
+func (l *Lox) TokenError(ttoken token.Token, message string) {
+	if ttoken.Type == token.EOF {
+		l.Error(ttoken.Line, "at end: "+message)
+	} else {
+		l.Error(ttoken.Line, fmt.Sprintf("at '%s': %s", ttoken.Lexeme, message))
+	}
+}
+
+// Warning reports a problem that doesn't stop the script from running.
+func (l *Lox) Warning(line int, message string) {
+	l.reporter().Warning(line, message)
+}
+
+func (l *Lox) TokenWarning(ttoken token.Token, message string) {
+	if ttoken.Type == token.EOF {
+		l.Warning(ttoken.Line, "at end: "+message)
+	} else {
+		l.Warning(ttoken.Line, fmt.Sprintf("at '%s': %s", ttoken.Lexeme, message))
+	}
+}
+
+func (l *Lox) runtimeError(err error) {
+	switch e := err.(type) {
+	case *RuntimeError:
+		fmt.Fprintf(l.stderr(), "%s\n[line %d]\n", e.Msg, e.Token.Line)
+	case *LimitError:
+		fmt.Fprintf(l.stderr(), "%s\n[line %d]\n", e.Error(), e.Token.Line)
+	case *Throw:
+		fmt.Fprintf(l.stderr(), "Uncaught exception: %s\n[line %d]\n", stringify(e.Value), e.Keyword.Line)
+	default:
+		fmt.Fprintf(l.stderr(), "%s\n", err)
+	}
+
 	l.HadRuntimeError = true
 }
+
+func (l *Lox) stdin() io.Reader {
+	if l.Stdin == nil {
+		return os.Stdin
+	}
+
+	return l.Stdin
+}
+
+func (l *Lox) stdout() io.Writer {
+	if l.Stdout == nil {
+		return os.Stdout
+	}
+
+	return l.Stdout
+}
+
+func (l *Lox) reporter() report.Reporter {
+	if l.Reporter == nil {
+		return &report.LoxReporter{Writer: l.stderr()}
+	}
+
+	return l.Reporter
+}
+
+func (l *Lox) stderr() io.Writer {
+	if l.Stderr == nil {
+		return os.Stderr
+	}
+
+	return l.Stderr
+}
@@ -0,0 +1,26 @@
+package lox
+
+import "fmt"
+
+type LoxCallable interface {
+	Arity() int
+	Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
+}
+
+// arityMismatch describes why count arguments can't be passed to function,
+// or returns "" if they can.
+func arityMismatch(function LoxCallable, count int) string {
+	if native, ok := function.(*NativeFunction); ok && native.variadic {
+		if count < native.arity {
+			return fmt.Sprintf("Expected at least %d arguments but got %d.", native.arity, count)
+		}
+
+		return ""
+	}
+
+	if count != function.Arity() {
+		return fmt.Sprintf("Expected %d arguments but got %d.", function.Arity(), count)
+	}
+
+	return ""
+}
@@ -0,0 +1,65 @@
+package lox
+
+type LoxClass struct {
+	Name       string
+	Superclass *LoxClass
+	Methods    map[string]*LoxFunction
+}
+
+func NewLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
+	return &LoxClass{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+	}
+}
+
+func (c *LoxClass) findMethod(name string) *LoxFunction {
+	if method, ok := c.Methods[name]; ok {
+		return method
+	}
+
+	if c.Superclass != nil {
+		return c.Superclass.findMethod(name)
+	}
+
+	return nil
+}
+
+// isSubclassOf reports whether c is other or inherits from it.
+func (c *LoxClass) isSubclassOf(other *LoxClass) bool {
+	for class := c; class != nil; class = class.Superclass {
+		if class == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *LoxClass) Arity() int {
+	initialiser := c.findMethod("init")
+	if initialiser == nil {
+		return 0
+	}
+
+	return initialiser.Arity()
+}
+
+func (c *LoxClass) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	instance := NewLoxInstance(c)
+
+	initialiser := c.findMethod("init")
+	if initialiser != nil {
+		_, err := initialiser.bind(instance).Call(interpreter, arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+func (c *LoxClass) String() string {
+	return c.Name
+}
@@ -0,0 +1,121 @@
+package lox
+
+import "testing"
+
+func TestClasses(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name:   "printing",
+			source: "class Point {} print Point; print Point();",
+			want:   "Point\nPoint instance\n",
+		},
+		{
+			name:   "fields",
+			source: "class Box {} var box = Box(); box.value = 1; box.value = box.value + 1;",
+			want:   "1\n2\n",
+		},
+		{
+			name: "methods and this",
+			source: `class Counter {
+				bump() { this.count = this.count + 1; return this; }
+			}
+			var c = Counter();
+			c.count = 0;
+			c.bump().bump().count;`,
+			want: "0\n2\n",
+		},
+		{
+			name: "bound methods",
+			source: `class Named {
+				init(name) { this.name = name; }
+				name() { return this.name; }
+			}
+			class Person {
+				init(name) { this.name = name; }
+				get() { return this.name; }
+			}
+			var get = Person("ada").get;
+			print get();`,
+			want: "ada\n",
+		},
+		{
+			name: "initialiser",
+			source: `class Point {
+				init(x, y) { this.x = x; this.y = y; }
+			}
+			var p = Point(1, 2);
+			print p.x + p.y;
+			print p.init(3, 4).x;`,
+			want: "3\n3\n",
+		},
+		{
+			name:   "early return from initialiser",
+			source: "class A { init() { this.a = 1; return; this.a = 2; } } print A().a;",
+			want:   "1\n",
+		},
+		{
+			name:   "returning a value from an initialiser",
+			source: "class A { init() { return 1; } }",
+			err:    "Can't return a value from an initialiser.",
+		},
+		{
+			name:   "this outside a class",
+			source: "print this;",
+			err:    "Can't use 'this' outside of a class.",
+		},
+	})
+}
+
+func TestInheritance(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name: "inherited methods",
+			source: `class A { method() { return 1; } }
+			class B < A {}
+			print B().method();`,
+			want: "1\n",
+		},
+		{
+			name: "overrides and super",
+			source: `class A { value() { return 1; } }
+			class B < A { value() { return super.value() + 10; } }
+			class C < B { value() { return super.value() + 100; } }
+			print C().value();`,
+			want: "111\n",
+		},
+		{
+			name: "super binds this",
+			source: `class A { name() { return this.n; } }
+			class B < A { init() { this.n = 2; } name() { return super.name(); } }
+			print B().name();`,
+			want: "2\n",
+		},
+		{
+			name: "inherited initialiser",
+			source: `class A { init(n) { this.n = n; } }
+			class B < A {}
+			print B(3).n;`,
+			want: "3\n",
+		},
+		{
+			name:   "inheriting from itself",
+			source: "class A < A {}",
+			err:    "A class can't inherit from itself.",
+		},
+		{
+			name:   "super outside a class",
+			source: "super.method();",
+			err:    "Can't use 'super' outside of a class.",
+		},
+		{
+			name:   "super without a superclass",
+			source: "class A { method() { super.method(); } }",
+			err:    "Can't use 'super' in a class with no superclass.",
+		},
+		{
+			name:   "superclass must be a class",
+			source: `var NotAClass = 1; class A < NotAClass {} print "after";`,
+			err:    "Superclass must be a class.",
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package lox
+
+import (
+	"github.com/dmcg310/glox/src/ast"
+	"github.com/dmcg310/glox/src/token"
+)
+
+type LoxFunction struct {
+	Declaration   *ast.Function
+	Closure       *Environment
+	globals       *Environment
+	isInitialiser bool
+}
+
+// globals is the top-level environment of the file the function was declared
+// in, which unresolved names inside it are looked up in even when it is
+// called from another module.
+func NewLoxFunction(declaration *ast.Function, closure, globals *Environment, isInitialiser bool) *LoxFunction {
+	return &LoxFunction{
+		Declaration:   declaration,
+		Closure:       closure,
+		globals:       globals,
+		isInitialiser: isInitialiser,
+	}
+}
+
+func (f *LoxFunction) bind(instance *LoxInstance) *LoxFunction {
+	environment := NewEnvironment(f.Closure)
+	environment.define("this", instance)
+
+	return NewLoxFunction(f.Declaration, environment, f.globals, f.isInitialiser)
+}
+
+func (f *LoxFunction) Arity() int {
+	return len(f.Declaration.Params)
+}
+
+func (f *LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	environment := NewEnvironment(f.Closure)
+	for idx, param := range f.Declaration.Params {
+		environment.define(param.Lexeme, arguments[idx])
+	}
+
+	prevGlobals := interpreter.Globals
+	interpreter.Globals = f.globals
+	defer func() {
+		interpreter.Globals = prevGlobals
+	}()
+
+	err := interpreter.executeBlock(f.Declaration.Body, environment)
+	if err != nil {
+		returnValue, ok := err.(*Return)
+		if !ok {
+			return nil, err
+		}
+
+		// an early "return;" from init still hands back the instance
+		if f.isInitialiser {
+			return f.Closure.getAt(0, "this"), nil
+		}
+
+		return returnValue.Value, nil
+	}
+
+	if f.isInitialiser {
+		return f.Closure.getAt(0, "this"), nil
+	}
+
+	return nil, nil
+}
+
+func (f *LoxFunction) String() string {
+	if f.Declaration.Name.Type != token.IDENTIFIER {
+		return "<fn lambda>"
+	}
+
+	return "<fn " + f.Declaration.Name.Lexeme + ">"
+}
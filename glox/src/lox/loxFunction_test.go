@@ -0,0 +1,99 @@
+package lox
+
+import "testing"
+
+func TestFunctions(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "call", source: `fun greet(name) { print name; } var r = greet("lox");`, want: "lox\n"},
+		{name: "return", source: "fun add(a, b) { return a + b; } print add(1, 2);", want: "3\n"},
+		{name: "bare return", source: "fun f() { return; } print f();", want: "nil\n"},
+		{name: "no return", source: "fun f() {} print f();", want: "nil\n"},
+		{
+			name:   "return unwinds blocks",
+			source: "fun f() { while (true) { { return 1; } } } print f();",
+			want:   "1\n",
+		},
+		{
+			name:   "recursion",
+			source: "fun fib(n) { if (n < 2) return n; return fib(n - 1) + fib(n - 2); } print fib(10);",
+			want:   "55\n",
+		},
+		{name: "printing", source: "fun f() {} print f; print clock;", want: "<fn f>\n<native fn>\n"},
+		{name: "echo", source: "fun f() { return 2; } f();", want: "2\n"},
+		{name: "arity", source: "fun f(a) {} f(1, 2); print \"after\";", err: "Expected 1 arguments but got 2."},
+		{name: "not callable", source: `"text"(); print "after";`, err: "Can only call functions and classes."},
+	})
+}
+
+func TestClosures(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name: "counter",
+			source: `fun makeCounter() {
+				var count = 0;
+				fun increment() { count = count + 1; return count; }
+				return increment;
+			}
+			var counter = makeCounter();
+			var a = counter();
+			var b = counter();
+			print b;`,
+			want: "2\n",
+		},
+		{
+			name: "independent closures",
+			source: `fun makeCounter() {
+				var count = 0;
+				fun increment() { count = count + 1; return count; }
+				return increment;
+			}
+			var first = makeCounter();
+			var second = makeCounter();
+			var a = first();
+			var b = first();
+			print second();`,
+			want: "1\n",
+		},
+		{
+			name: "shared mutation",
+			source: `var get;
+			var set;
+			{
+				var value = 1;
+				fun getter() { return value; }
+				fun setter(v) { value = v; }
+				get = getter;
+				set = setter;
+				value = 2;
+			}
+			print get();
+			var r = set(3);
+			print get();`,
+			want: "2\n3\n",
+		},
+	})
+}
+
+func TestLambdas(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "fun expression", source: "var add = fun (a, b) { return a + b; }; print add(1, 2);", want: "3\n"},
+		{name: "arrow expression", source: "var square = (n) => n * n; print square(4);", want: "16\n"},
+		{name: "arrow block", source: "var f = (a) => { var b = a + 1; return b; }; print f(1);", want: "2\n"},
+		{name: "no parameters", source: "var f = () => 7; print f();", want: "7\n"},
+		{name: "printing", source: "print fun () {}; print () => 1;", want: "<fn lambda>\n<fn lambda>\n"},
+		{
+			name:   "passed as arguments",
+			source: "fun apply(f, x) { return f(x); } print apply((x) => x + 1, 1); print apply(fun (x) { return x * 10; }, 2);",
+			want:   "2\n20\n",
+		},
+		{
+			name:   "closures",
+			source: "fun adder(n) { return (x) => x + n; } var add2 = adder(2); print add2(3);",
+			want:   "5\n",
+		},
+		{name: "immediately invoked", source: "print ((x) => x * 2)(21);", want: "42\n"},
+		{name: "groupings still parse", source: "var a = 1; print (a) + 1; print (a + 1) * 2;", want: "2\n4\n"},
+		{name: "anonymous statement", source: "fun () {};", want: "<fn lambda>\n"},
+		{name: "missing arrow body", source: "var f = (a) => ;", err: "Expect expression."},
+	})
+}
@@ -0,0 +1,39 @@
+package lox
+
+import "github.com/dmcg310/glox/src/token"
+
+type LoxInstance struct {
+	Class  *LoxClass
+	fields map[string]interface{}
+}
+
+func NewLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{
+		Class:  class,
+		fields: make(map[string]interface{}),
+	}
+}
+
+func (li *LoxInstance) get(name token.Token) (interface{}, error) {
+	if val, ok := li.fields[name.Lexeme]; ok {
+		return val, nil
+	}
+
+	method := li.Class.findMethod(name.Lexeme)
+	if method != nil {
+		return method.bind(li), nil
+	}
+
+	return nil, &RuntimeError{
+		Token: name,
+		Msg:   "Undefined property '" + name.Lexeme + "'.",
+	}
+}
+
+func (li *LoxInstance) set(name token.Token, value interface{}) {
+	li.fields[name.Lexeme] = value
+}
+
+func (li *LoxInstance) String() string {
+	return li.Class.Name + " instance"
+}
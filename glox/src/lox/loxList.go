@@ -0,0 +1,33 @@
+package lox
+
+import "github.com/dmcg310/glox/src/token"
+
+type LoxList struct {
+	Elements []interface{}
+}
+
+func NewLoxList(elements []interface{}) *LoxList {
+	return &LoxList{
+		Elements: elements,
+	}
+}
+
+func (l *LoxList) get(bracket token.Token, index interface{}) (interface{}, error) {
+	idx, err := checkIndex(bracket, index, len(l.Elements))
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Elements[idx], nil
+}
+
+func (l *LoxList) set(bracket token.Token, index interface{}, value interface{}) error {
+	idx, err := checkIndex(bracket, index, len(l.Elements))
+	if err != nil {
+		return err
+	}
+
+	l.Elements[idx] = value
+
+	return nil
+}
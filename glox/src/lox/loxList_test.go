@@ -0,0 +1,43 @@
+package lox
+
+import "testing"
+
+func TestLists(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "literals", source: `print [1, "a", nil, [true]]; print [];`, want: "[1, \"a\", nil, [true]]\n[]\n"},
+		{name: "trailing comma", source: "print [1, 2,];", want: "[1, 2]\n"},
+		{name: "indexing", source: "var a = [1, [2, 3]]; print a[0]; print a[1][1];", want: "1\n3\n"},
+		{name: "index assignment", source: "var a = [1, 2]; a[1] = a[0] + 5; print a;", want: "6\n[1, 6]\n"},
+		{
+			name:   "index assignment evaluates to the value",
+			source: "var a = [0]; print a[0] = 2;",
+			want:   "2\n",
+		},
+		{
+			name:   "builtins",
+			source: "var a = [1]; print push(a, 2); print len(a); print pop(a); print a; print len(\"four\");",
+			want:   "2\n2\n2\n[1]\n4\n",
+		},
+		{
+			name:   "lists are shared by reference",
+			source: "var a = [1]; var b = a; var r = push(b, 2); print a; print a == b; print a == [1, 2];",
+			want:   "[1, 2]\ntrue\nfalse\n",
+		},
+		{
+			name:   "a list that contains itself",
+			source: "var a = [1]; var r = push(a, a); var s = push(a, [a]); print a;",
+			want:   "[1, [...], [[...]]]\n",
+		},
+		{
+			name:   "a list shared without a cycle",
+			source: "var a = [1]; print [a, a];",
+			want:   "[[1], [1]]\n",
+		},
+		{name: "string concatenation", source: `print "a" + "b";`, want: "ab\n"},
+		{name: "fractional index", source: `var a = [1]; print a[0.5]; print "after";`, err: "Index must be an integer."},
+		{name: "negative index", source: `var a = [1]; print a[-1]; print "after";`, err: "Index can't be negative."},
+		{name: "index out of range", source: `var a = [1]; print a[1]; print "after";`, err: "Index out of range."},
+		{name: "popping an empty list", source: `print pop([]); print "after";`, err: "Can't pop from an empty list."},
+		{name: "unterminated literal", source: "print [1, 2;", err: "Expect ']' after list elements."},
+	})
+}
@@ -0,0 +1,151 @@
+package lox
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/dmcg310/glox/src/decimal"
+	"github.com/dmcg310/glox/src/token"
+)
+
+// LoxMap keys use Go equality on the underlying values, which matches
+// Interpreter.isEqual: numbers, strings, booleans and nil compare by value
+// and everything else by identity. Numbers are looked up by a canonical key
+// so that 1, 1.0, 1n and 1.0d name the same entry, while the key as first
+// written is kept for printing. NaN isn't equal to itself, so every NaN is
+// given the same key, otherwise a NaN entry could never be found or
+// deleted again. Keys are kept in insertion order so that
+// printing and iteration are deterministic.
+type LoxMap struct {
+	keys    []interface{}
+	entries map[interface{}]*mapEntry
+}
+
+type mapEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// nanKey is the canonical key of every NaN.
+type nanKey struct{}
+
+// numberKey is the canonical key of a number that neither fits an int64 nor
+// is exactly a float, written as an exact rational.
+type numberKey string
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{
+		keys:    []interface{}{},
+		entries: make(map[interface{}]*mapEntry),
+	}
+}
+
+func (m *LoxMap) get(bracket token.Token, key interface{}) (interface{}, error) {
+	if entry, ok := m.entries[mapKey(key)]; ok {
+		return entry.value, nil
+	}
+
+	return nil, &RuntimeError{Token: bracket, Msg: "Key not found in map."}
+}
+
+func (m *LoxMap) set(key interface{}, value interface{}) {
+	canonical := mapKey(key)
+	if entry, ok := m.entries[canonical]; ok {
+		entry.value = value
+		return
+	}
+
+	m.keys = append(m.keys, canonical)
+	m.entries[canonical] = &mapEntry{key: key, value: value}
+}
+
+func (m *LoxMap) has(key interface{}) bool {
+	_, ok := m.entries[mapKey(key)]
+
+	return ok
+}
+
+func (m *LoxMap) delete(key interface{}) bool {
+	key = mapKey(key)
+	if _, ok := m.entries[key]; !ok {
+		return false
+	}
+
+	delete(m.entries, key)
+	for idx, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:idx], m.keys[idx+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+// Get looks up key, reporting whether it was present.
+func (m *LoxMap) Get(key interface{}) (interface{}, bool) {
+	entry, ok := m.entries[mapKey(key)]
+	if !ok {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (m *LoxMap) Set(key interface{}, value interface{}) {
+	m.set(key, value)
+}
+
+func (m *LoxMap) Len() int {
+	return len(m.keys)
+}
+
+func (m *LoxMap) Keys() []interface{} {
+	keys := make([]interface{}, len(m.keys))
+	for idx, key := range m.keys {
+		keys[idx] = m.entries[key].key
+	}
+
+	return keys
+}
+
+func (m *LoxMap) Values() []interface{} {
+	values := make([]interface{}, len(m.keys))
+	for idx, key := range m.keys {
+		values[idx] = m.entries[key].value
+	}
+
+	return values
+}
+
+func mapKey(key interface{}) interface{} {
+	var rat *big.Rat
+	switch num := key.(type) {
+	case float64:
+		if math.IsNaN(num) {
+			return nanKey{}
+		}
+
+		if integer, ok := toInteger(num); ok {
+			return integer
+		}
+
+		return num
+	case *big.Int:
+		rat = new(big.Rat).SetInt(num)
+	case *decimal.Decimal:
+		rat = num.Rat()
+	default:
+		return key
+	}
+
+	if rat.IsInt() && rat.Num().IsInt64() {
+		return rat.Num().Int64()
+	}
+
+	if f, exact := rat.Float64(); exact {
+		return f
+	}
+
+	return numberKey(rat.RatString())
+}
@@ -0,0 +1,48 @@
+package lox
+
+import "testing"
+
+func TestMaps(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name:   "literals",
+			source: `var m = {"a": 1, 2: [true], nil: {}}; print m; print {};`,
+			want:   "{\"a\": 1, 2: [true], nil: {}}\n{}\n",
+		},
+		{name: "lookup", source: `var m = {"a": 1, 2: "two"}; print m["a"]; print m[1 + 1];`, want: "1\ntwo\n"},
+		{
+			name:   "assignment keeps insertion order",
+			source: `var m = {"b": 1}; m["a"] = 2; m["b"] = 3; print m;`,
+			want:   "2\n3\n{\"b\": 3, \"a\": 2}\n",
+		},
+		{
+			name:   "builtins",
+			source: `var m = {"a": 1, "b": 2}; print len(m); print has(m, "a"); print delete(m, "a"); print delete(m, "a"); print has(m, "a"); print keys(m); print values(m);`,
+			want:   "2\ntrue\ntrue\nfalse\nfalse\n[\"b\"]\n[2]\n",
+		},
+		{
+			name:   "numbers and strings are distinct keys",
+			source: `var m = {1: "number", "1": "string"}; print m[1]; print m["1"];`,
+			want:   "number\nstring\n",
+		},
+		{
+			name:   "lists are keys by identity",
+			source: `var key = [1]; var m = {}; m[key] = "found"; print m[key]; print has(m, [1]);`,
+			want:   "found\nfound\nfalse\n",
+		},
+		{
+			name:   "a map that contains itself",
+			source: `var m = {}; { m["a"] = m; m["b"] = [m]; } print m;`,
+			want:   "{\"a\": {...}, \"b\": [{...}]}\n",
+		},
+		{
+			name:   "NaN keys",
+			source: `var nan = 0.0 / 0.0; var m = {nan: 1}; print m[nan]; print delete(m, nan); print len(m);`,
+			want:   "1\ntrue\n0\n",
+		},
+		{name: "missing key", source: `var m = {}; print m["missing"]; print "after";`, err: "Key not found in map."},
+		{name: "indexing a number", source: `var n = 1; print n[0]; print "after";`, err: "Only lists, maps and strings can be indexed."},
+		{name: "missing colon", source: `var m = {"a" 1};`, err: "Expect ':' after map key."},
+		{name: "unterminated literal", source: `var m = {"a": 1;`, err: "Expect '}' after map entries."},
+	})
+}
@@ -0,0 +1,73 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+)
+
+type scriptTest struct {
+	name   string
+	source string
+	want   string
+	err    string
+}
+
+// run executes source in a fresh Lox, returning what it printed and the
+// errors it reported.
+func run(t *testing.T, source string) (string, string) {
+	t.Helper()
+
+	var out, errors strings.Builder
+	l := Lox{
+		Interpreter: NewInterpreter(),
+		Stdout:      &out,
+		Stderr:      &errors,
+	}
+	l.Run(source)
+
+	return out.String(), errors.String()
+}
+
+// runTests runs each script, comparing its output with want and its errors
+// with err. An empty err means the script must run cleanly.
+func runTests(t *testing.T, tests []scriptTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, errors := run(t, test.source)
+
+			if test.err == "" && errors != "" {
+				t.Errorf("unexpected errors:\n%s", errors)
+			} else if !strings.Contains(errors, test.err) {
+				t.Errorf("errors = %q, want %q", errors, test.err)
+			}
+
+			if out != test.want {
+				t.Errorf("output = %q, want %q", out, test.want)
+			}
+		})
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	var out, errors strings.Builder
+	l := Lox{
+		Interpreter: NewInterpreter(),
+		Stdin:       strings.NewReader("var a = 1;\na + 1;\nprint a;\nprint nil[0];\n"),
+		Stdout:      &out,
+		Stderr:      &errors,
+	}
+
+	if err := l.RunPrompt(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "> > 2\n> 1\n> > "; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	if want := "Only lists, maps and strings can be indexed.\n[line 1]\n"; errors.String() != want {
+		t.Errorf("errors = %q, want %q", errors.String(), want)
+	}
+}
@@ -0,0 +1,195 @@
+package lox
+
+import (
+	"fmt"
+
+	"github.com/dmcg310/glox/src/ast"
+	"github.com/dmcg310/glox/src/token"
+)
+
+func (i *Interpreter) VisitMatch(expr *ast.Match) (interface{}, error) {
+	subject, err := i.evaluate(expr.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	value, matched, err := i.matchArms(subject, expr.Arms)
+	if err != nil {
+		return nil, err
+	}
+
+	// a match used for its value has nothing to give back if no arm applies
+	if !matched {
+		return nil, &RuntimeError{
+			Token: expr.Keyword,
+			Msg:   "No match arm matched " + stringifyElement(subject, nil) + ".",
+		}
+	}
+
+	return value, nil
+}
+
+func (i *Interpreter) VisitMatchStmt(stmt *ast.MatchStmt) (interface{}, error) {
+	subject, err := i.evaluate(stmt.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = i.matchArms(subject, stmt.Arms)
+
+	return nil, err
+}
+
+// matchArms runs the first arm whose pattern matches subject and whose
+// guard, if any, is truthy. It reports whether any arm ran.
+func (i *Interpreter) matchArms(subject interface{}, arms []*ast.MatchArm) (interface{}, bool, error) {
+	for _, arm := range arms {
+		value, matched, err := i.matchArm(subject, arm)
+		if err != nil || matched {
+			return value, matched, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+// matchArm tries a single arm in a fresh environment holding the variables
+// its pattern binds, which the guard and body then see.
+func (i *Interpreter) matchArm(subject interface{}, arm *ast.MatchArm) (interface{}, bool, error) {
+	prev := i.Environment
+	i.Environment = NewEnvironment(prev)
+
+	defer func() {
+		i.Environment = prev
+	}()
+
+	matched, err := i.matchPattern(arm.Pattern, subject)
+	if err != nil || !matched {
+		return nil, false, err
+	}
+
+	if arm.Guard != nil {
+		guard, err := i.evaluate(arm.Guard)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !i.isTruthy(guard) {
+			return nil, false, nil
+		}
+	}
+
+	if body, ok := arm.Body.(*ast.Expression); ok {
+		value, err := i.evaluate(body.Expression)
+		return value, true, err
+	}
+
+	_, err = i.execute(arm.Body)
+
+	return nil, true, err
+}
+
+// matchPattern tests value against pattern, defining any variables the
+// pattern binds in the current environment as it goes.
+func (i *Interpreter) matchPattern(pattern ast.Pattern, value interface{}) (bool, error) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.LiteralPattern:
+		return i.isEqual(pattern.Value, value), nil
+	case *ast.BindingPattern:
+		i.Environment.define(pattern.Name.Lexeme, value)
+		return true, nil
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			matched, err := i.matchPattern(alternative, value)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+
+		return false, nil
+	case *ast.ListPattern:
+		return i.matchList(pattern, value)
+	case *ast.ClassPattern:
+		return i.matchInstance(pattern, value)
+	case *ast.ObjectPattern:
+		return i.matchFields(pattern.Fields, pattern.Patterns, value)
+	}
+
+	return false, fmt.Errorf("unknown pattern: %T", pattern)
+}
+
+func (i *Interpreter) matchList(pattern *ast.ListPattern, value interface{}) (bool, error) {
+	list, ok := value.(*LoxList)
+	if !ok {
+		return false, nil
+	}
+
+	count := len(pattern.Elements)
+	if len(list.Elements) < count || (!pattern.HasRest && len(list.Elements) != count) {
+		return false, nil
+	}
+
+	for idx, element := range pattern.Elements {
+		matched, err := i.matchPattern(element, list.Elements[idx])
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if pattern.Rest == nil {
+		return true, nil
+	}
+
+	return i.matchPattern(pattern.Rest, restOf(list.Elements, count))
+}
+
+func (i *Interpreter) matchInstance(pattern *ast.ClassPattern, value interface{}) (bool, error) {
+	callee, err := i.evaluate(pattern.Class)
+	if err != nil {
+		return false, err
+	}
+
+	class, ok := callee.(*LoxClass)
+	if !ok {
+		return false, &RuntimeError{Token: pattern.Class.Name, Msg: "Can only match instances against a class."}
+	}
+
+	instance, ok := value.(*LoxInstance)
+	if !ok || !instance.Class.isSubclassOf(class) {
+		return false, nil
+	}
+
+	return i.matchFields(pattern.Fields, pattern.Patterns, instance)
+}
+
+// matchFields matches the fields of an instance, or the string keys of a
+// map, against the pattern given for each. A missing field doesn't match.
+func (i *Interpreter) matchFields(fields []token.Token, patterns []ast.Pattern, value interface{}) (bool, error) {
+	for idx, field := range fields {
+		var fieldValue interface{}
+		var ok bool
+
+		switch object := value.(type) {
+		case *LoxInstance:
+			fieldValue, ok = object.fields[field.Lexeme]
+		case *LoxMap:
+			ok = object.has(field.Lexeme)
+			if ok {
+				fieldValue, _ = object.get(field, field.Lexeme)
+			}
+		}
+
+		if !ok {
+			return false, nil
+		}
+
+		matched, err := i.matchPattern(patterns[idx], fieldValue)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
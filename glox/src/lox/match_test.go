@@ -0,0 +1,113 @@
+package lox
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name: "literals",
+			source: `fun describe(x) {
+				match (x) {
+					case 0 => print "zero";
+					case -1 => print "minus one";
+					case "a" | "b" => print "letter";
+					case nil => print "nothing";
+					_ => print "other";
+				}
+			}
+			var a = describe(0);
+			var b = describe(-1);
+			var c = describe("b");
+			var d = describe(nil);
+			var e = describe(true);`,
+			want: "zero\nminus one\nletter\nnothing\nother\n",
+		},
+		{
+			name:   "expression",
+			source: `var x = 2; print match (x) { case 1 => "one", case 2 => "two", _ => "many" };`,
+			want:   "two\n",
+		},
+		{
+			name:   "binding",
+			source: `match (41) { case n => print n + 1; }`,
+			want:   "42\n",
+		},
+		{
+			name: "lists",
+			source: `fun head(l) {
+				return match (l) {
+					case [] => "empty",
+					case [x] => "one ${x}",
+					case [x, ...rest] => "${x} then ${rest}",
+				};
+			}
+			print head([]); print head([1]); print head([1, 2, 3]);`,
+			want: "empty\none 1\n1 then [2, 3]\n",
+		},
+		{
+			name: "classes",
+			source: `class Point { init(x, y) { this.x = x; this.y = y; } }
+			class Point3 < Point {}
+			fun where(p) {
+				return match (p) {
+					case Point{x: 0, y} => "on the y axis at ${y}",
+					case Point{x, y: 0} => "on the x axis at ${x}",
+					case Point{} => "elsewhere",
+					_ => "not a point",
+				};
+			}
+			print where(Point(0, 2)); print where(Point3(3, 0)); print where(Point(1, 1)); print where(1);`,
+			want: "on the y axis at 2\non the x axis at 3\nelsewhere\nnot a point\n",
+		},
+		{
+			name:   "guards",
+			source: `fun sign(n) { return match (n) { case x if x < 0 => "negative", case 0 => "zero", _ => "positive" }; } print sign(-5); print sign(0); print sign(3);`,
+			want:   "negative\nzero\npositive\n",
+		},
+		{
+			name:   "bindings are scoped to the arm",
+			source: `var x = "outer"; match (1) { case x => print x; } print x;`,
+			want:   "1\nouter\n",
+		},
+		{
+			name:   "statements need no matching arm",
+			source: `match (3) { case 1 => print "one"; } print "after";`,
+			want:   "after\n",
+		},
+		{
+			name:   "no arm matches",
+			source: `print match ("c") { case "a" => 1 };`,
+			err:    `No match arm matched "c".`,
+		},
+		{
+			name:   "unreachable arm",
+			source: `match (1) { _ => print "any"; case 1 => print "one"; }`,
+			want:   "any\n",
+			err:    "Warning: at 'case': Unreachable match arm.",
+		},
+		{
+			name:   "binding in alternatives",
+			source: `match (1) { case a | 2 => print a; }`,
+			err:    "Can't bind variables in alternative patterns.",
+		},
+		{
+			name:   "matching a non-instance against a class",
+			source: `var NotAClass = 1; match (1) { case NotAClass{} => print "no"; }`,
+			err:    "Can only match instances against a class.",
+		},
+		{
+			name: "match and case are still names",
+			source: `var case = 1;
+			fun match(x) { return x + case; }
+			print match(case);
+			var r = match (case) { case 1 => "one", _ => "other" };
+			print r;`,
+			want: "2\none\n",
+		},
+		{
+			name:   "missing arrow",
+			source: `match (1) { case 1 print "one"; }`,
+			err:    "Expect '=>' after match pattern.",
+		},
+	})
+}
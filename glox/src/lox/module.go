@@ -0,0 +1,152 @@
+package lox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmcg310/glox/src/scanner"
+	"github.com/dmcg310/glox/src/token"
+)
+
+// LoxModule is the namespace produced by running a file on import. Every
+// top-level binding in the file is exported through its globals.
+type LoxModule struct {
+	Name    string
+	Path    string
+	Globals *Environment
+}
+
+func (m *LoxModule) get(name token.Token) (interface{}, error) {
+	if val, ok := m.Globals.values[name.Lexeme]; ok {
+		return val, nil
+	}
+
+	return nil, &RuntimeError{
+		Token: name,
+		Msg:   "Module '" + m.Name + "' has no export '" + name.Lexeme + "'.",
+	}
+}
+
+func (m *LoxModule) String() string {
+	return "<module " + m.Name + ">"
+}
+
+type Importer interface {
+	Import(keyword token.Token, path string) (*LoxModule, error)
+}
+
+// Import resolves path relative to the file currently being run, falling back
+// to each SearchPath entry, and runs the module the first time it is seen.
+func (l *Lox) Import(keyword token.Token, path string) (*LoxModule, error) {
+	resolved, err := l.resolveModule(path)
+	if err != nil {
+		return nil, &RuntimeError{Token: keyword, Msg: err.Error()}
+	}
+
+	if module, ok := l.modules[resolved]; ok {
+		return module, nil
+	}
+
+	for idx, loading := range l.loading {
+		if loading == resolved {
+			cycle := append(append([]string{}, l.loading[idx:]...), resolved)
+			for i, file := range cycle {
+				cycle[i] = filepath.Base(file)
+			}
+
+			return nil, &RuntimeError{
+				Token: keyword,
+				Msg:   "Import cycle detected: " + strings.Join(cycle, " -> ") + ".",
+			}
+		}
+	}
+
+	bytes, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, &RuntimeError{Token: keyword, Msg: fmt.Sprintf("Could not read module '%s'.", path)}
+	}
+
+	l.loading = append(l.loading, resolved)
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+	}()
+
+	module, err := l.runModule(resolved, string(bytes))
+	if err != nil {
+		return nil, err
+	}
+
+	if module == nil {
+		return nil, &RuntimeError{Token: keyword, Msg: fmt.Sprintf("Could not compile module '%s'.", path)}
+	}
+
+	if l.modules == nil {
+		l.modules = make(map[string]*LoxModule)
+	}
+	l.modules[resolved] = module
+
+	return module, nil
+}
+
+func (l *Lox) resolveModule(path string) (string, error) {
+	candidates := []string{}
+	if filepath.IsAbs(path) {
+		candidates = append(candidates, path)
+	} else {
+		dir := "."
+		if len(l.loading) > 0 {
+			dir = filepath.Dir(l.loading[len(l.loading)-1])
+		}
+
+		candidates = append(candidates, filepath.Join(dir, path))
+		for _, searchDir := range l.SearchPath {
+			candidates = append(candidates, filepath.Join(searchDir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+
+	return "", fmt.Errorf("Could not find module '%s'.", path)
+}
+
+// runModule runs source in a fresh global environment of its own. A nil
+// module with no error means the source failed to scan, parse or resolve,
+// which has already been reported.
+func (l *Lox) runModule(path, source string) (*LoxModule, error) {
+	hadError := l.HadError
+	l.HadError = false
+	defer func() {
+		l.HadError = l.HadError || hadError
+	}()
+
+	_scanner := scanner.NewScanner(source, l)
+	tokens := _scanner.ScanTokens()
+	parser := NewParser(tokens, l)
+	statements := parser.Parse()
+
+	if l.HadError {
+		return nil, nil
+	}
+
+	resolver := NewResolver(&l.Interpreter, l)
+	resolver.resolve(statements)
+
+	if l.HadError {
+		return nil, nil
+	}
+
+	globals := NewEnvironment(l.Interpreter.builtins)
+	if err := l.Interpreter.executeModule(statements, globals); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	return &LoxModule{Name: name, Path: path, Globals: globals}, nil
+}
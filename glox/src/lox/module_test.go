@@ -0,0 +1,110 @@
+package lox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModules writes each file into a fresh directory, replacing $DIR in
+// its contents with that directory, and returns the directory.
+func writeModules(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, contents := range files {
+		contents = strings.ReplaceAll(contents, "$DIR", dir)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestImports(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"math.lox": `print "loading math";
+			var pi = 3;
+			fun double(n) { return n * 2; }`,
+		"uses.lox":   `import "math.lox" as m; var six = m.double(m.pi);`,
+		"a.lox":      `import "$DIR/b.lox" as b;`,
+		"b.lox":      `import "$DIR/a.lox" as a;`,
+		"broken.lox": "var = 1;",
+		"private.lox": `var hidden = "module";
+			fun get() { return hidden; }`,
+	})
+
+	tests := []scriptTest{
+		{
+			name:   "namespace",
+			source: `import "$DIR/math.lox" as math; print math.double(math.pi); print math;`,
+			want:   "loading math\n6\n<module math>\n",
+		},
+		{
+			name:   "named imports",
+			source: `import {pi, double} from "$DIR/math.lox"; print double(pi);`,
+			want:   "loading math\n6\n",
+		},
+		{
+			name:   "modules run once",
+			source: `import "$DIR/math.lox" as a; import "$DIR/math.lox" as b; print a.pi + b.pi;`,
+			want:   "loading math\n6\n",
+		},
+		{
+			name:   "relative to the importing module",
+			source: `import {six} from "$DIR/uses.lox"; print six;`,
+			want:   "loading math\n6\n",
+		},
+		{
+			name:   "modules have their own globals",
+			source: `var hidden = "script"; import {get} from "$DIR/private.lox"; print get(); print hidden;`,
+			want:   "module\nscript\n",
+		},
+		{
+			name:   "missing export",
+			source: `import {tau} from "$DIR/math.lox";`,
+			want:   "loading math\n",
+			err:    "Module 'math' has no export 'tau'.",
+		},
+		{
+			name:   "missing module",
+			source: `import "$DIR/missing.lox" as missing;`,
+			err:    "Could not find module '$DIR/missing.lox'.",
+		},
+		{
+			name:   "cycle",
+			source: `import "$DIR/a.lox" as a;`,
+			err:    "Import cycle detected: a.lox -> b.lox -> a.lox.",
+		},
+		{
+			name:   "compile errors",
+			source: `import "$DIR/broken.lox" as broken;`,
+			err:    "Could not compile module '$DIR/broken.lox'.",
+		},
+		{
+			name:   "as and from are still names",
+			source: `import "$DIR/math.lox" as as; var from = as.pi; fun f(as) { return as; } print f(from);`,
+			want:   "loading math\n3\n",
+		},
+		{
+			name:   "assigning to a module member",
+			source: `import "$DIR/math.lox" as m; m.pi = 4; print "after";`,
+			want:   "loading math\n",
+			err:    "Can't assign to module members.",
+		},
+		{
+			name:   "missing alias",
+			source: `import "math.lox";`,
+			err:    "Expect 'as' after module path.",
+		},
+	}
+
+	for idx := range tests {
+		tests[idx].source = strings.ReplaceAll(tests[idx].source, "$DIR", dir)
+		tests[idx].err = strings.ReplaceAll(tests[idx].err, "$DIR", dir)
+	}
+
+	runTests(t, tests)
+}
@@ -0,0 +1,257 @@
+package lox
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dmcg310/glox/src/decimal"
+)
+
+// NativeFunction is a function implemented in Go. A variadic native takes
+// at least arity arguments rather than exactly that many.
+type NativeFunction struct {
+	Name     string
+	arity    int
+	variadic bool
+	fn       func(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
+}
+
+func (n *NativeFunction) Arity() int {
+	return n.arity
+}
+
+func (n *NativeFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return n.fn(interpreter, arguments)
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+func defineNatives(globals *Environment) {
+	natives := []*NativeFunction{
+		{Name: "clock", arity: 0, fn: clock},
+		{Name: "len", arity: 1, fn: length},
+		{Name: "push", arity: 2, fn: push},
+		{Name: "pop", arity: 1, fn: pop},
+		{Name: "has", arity: 2, fn: has},
+		{Name: "delete", arity: 2, fn: deleteKey},
+		{Name: "keys", arity: 1, fn: keys},
+		{Name: "values", arity: 1, fn: values},
+		{Name: "slice", arity: 3, fn: slice},
+		{Name: "int", arity: 1, fn: toInt},
+		{Name: "float", arity: 1, fn: toFloatNative},
+		{Name: "bigint", arity: 1, fn: toBigInt},
+		{Name: "decimal", arity: 1, fn: toDecimalNative},
+	}
+
+	for _, native := range natives {
+		globals.define(native.Name, native)
+	}
+}
+
+func clock(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return float64(time.Now().UnixMilli()) / 1000.0, nil
+}
+
+func length(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case *LoxList:
+		return int64(len(value.Elements)), nil
+	case *LoxMap:
+		return int64(value.Len()), nil
+	case string:
+		return int64(utf8.RuneCountInString(value)), nil
+	}
+
+	return nil, errors.New("Can only take the length of lists, maps and strings.")
+}
+
+func push(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, errors.New("Can only push onto a list.")
+	}
+
+	list.Elements = append(list.Elements, arguments[1])
+
+	return int64(len(list.Elements)), nil
+}
+
+func pop(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, errors.New("Can only pop from a list.")
+	}
+
+	if len(list.Elements) == 0 {
+		return nil, errors.New("Can't pop from an empty list.")
+	}
+
+	last := list.Elements[len(list.Elements)-1]
+	list.Elements = list.Elements[:len(list.Elements)-1]
+
+	return last, nil
+}
+
+func has(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	m, ok := arguments[0].(*LoxMap)
+	if !ok {
+		return nil, errors.New("Can only check keys of a map.")
+	}
+
+	return m.has(arguments[1]), nil
+}
+
+func deleteKey(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	m, ok := arguments[0].(*LoxMap)
+	if !ok {
+		return nil, errors.New("Can only delete keys from a map.")
+	}
+
+	return m.delete(arguments[1]), nil
+}
+
+func keys(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	m, ok := arguments[0].(*LoxMap)
+	if !ok {
+		return nil, errors.New("Can only list the keys of a map.")
+	}
+
+	return NewLoxList(m.Keys()), nil
+}
+
+func values(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	m, ok := arguments[0].(*LoxMap)
+	if !ok {
+		return nil, errors.New("Can only list the values of a map.")
+	}
+
+	return NewLoxList(m.Values()), nil
+}
+
+// slice returns the elements of a list, or the code points of a string,
+// from start up to but not including end.
+func slice(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	start, startOk := toInteger(arguments[1])
+	end, endOk := toInteger(arguments[2])
+	if !startOk || !endOk {
+		return nil, errors.New("Slice bounds must be integers.")
+	}
+
+	switch value := arguments[0].(type) {
+	case *LoxList:
+		if start < 0 || end < start || end > int64(len(value.Elements)) {
+			return nil, errors.New("Slice bounds out of range.")
+		}
+
+		elements := make([]interface{}, int(end-start))
+		copy(elements, value.Elements[int(start):int(end)])
+
+		return NewLoxList(elements), nil
+	case string:
+		runes := []rune(value)
+		if start < 0 || end < start || end > int64(len(runes)) {
+			return nil, errors.New("Slice bounds out of range.")
+		}
+
+		return string(runes[int(start):int(end)]), nil
+	}
+
+	return nil, errors.New("Can only slice lists and strings.")
+}
+
+// toInt converts a number or numeric string to an integer, truncating any
+// fraction. Values too large for an int64 come back as BigInts.
+func toInt(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	integer, err := integerPart(arguments[0], "an integer")
+	if err != nil {
+		return nil, err
+	}
+
+	if integer.IsInt64() {
+		return integer.Int64(), nil
+	}
+
+	return integer, nil
+}
+
+func toBigInt(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return integerPart(arguments[0], "a BigInt")
+}
+
+func toFloatNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if str, ok := arguments[0].(string); ok {
+		val, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return nil, errors.New("Can't convert '" + str + "' to a float.")
+		}
+
+		return val, nil
+	}
+
+	if !isNumber(arguments[0]) {
+		return nil, errors.New("Can only convert numbers and strings to a float.")
+	}
+
+	return toFloat(arguments[0]), nil
+}
+
+// toDecimalNative converts floats by their shortest printed form, so
+// decimal(0.1) is exactly 0.1 rather than the nearest binary fraction.
+func toDecimalNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case int64, *big.Int, *decimal.Decimal:
+		return toDecimal(value), nil
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, errors.New("Can't convert " + strconv.FormatFloat(value, 'f', -1, 64) + " to a decimal.")
+		}
+
+		val, _ := decimal.Parse(strconv.FormatFloat(value, 'g', -1, 64))
+		return val, nil
+	case string:
+		val, ok := decimal.Parse(strings.TrimSpace(value))
+		if !ok {
+			return nil, errors.New("Can't convert '" + value + "' to a decimal.")
+		}
+
+		return val, nil
+	}
+
+	return nil, errors.New("Can only convert numbers and strings to a decimal.")
+}
+
+// integerPart truncates a number towards zero, or parses a string of
+// decimal digits, as a BigInt.
+func integerPart(value interface{}, kind string) (*big.Int, error) {
+	switch num := value.(type) {
+	case int64:
+		return big.NewInt(num), nil
+	case *big.Int:
+		return num, nil
+	case *decimal.Decimal:
+		return num.Int(), nil
+	case float64:
+		if math.IsNaN(num) || math.IsInf(num, 0) {
+			return nil, errors.New("Can't convert " + strconv.FormatFloat(num, 'f', -1, 64) + " to " + kind + ".")
+		}
+
+		integer, _ := big.NewFloat(math.Trunc(num)).Int(nil)
+		return integer, nil
+	case string:
+		integer, ok := new(big.Int).SetString(strings.ReplaceAll(strings.TrimSpace(num), "_", ""), 10)
+		if !ok {
+			return nil, errors.New("Can't convert '" + num + "' to " + kind + ".")
+		}
+
+		return integer, nil
+	}
+
+	return nil, errors.New("Can only convert numbers and strings to " + kind + ".")
+}
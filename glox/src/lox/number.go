@@ -0,0 +1,513 @@
+package lox
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/dmcg310/glox/src/decimal"
+	"github.com/dmcg310/glox/src/token"
+)
+
+// Lox numbers come in four kinds, from narrowest to widest: int64 for
+// integer literals, *big.Int for BigInt literals and integer results that
+// overflow an int64, *decimal.Decimal for exact decimals and float64.
+// Arithmetic between two kinds is carried out in the wider of the two.
+type numberKind int
+
+const (
+	INT_KIND numberKind = iota
+	BIG_KIND
+	DECIMAL_KIND
+	FLOAT_KIND
+)
+
+// maxResultBits bounds the size of an integer or decimal result. math/big
+// can't be interrupted, so a result like 2 ** 4000000000 would otherwise
+// hang the interpreter past any timeout.
+const maxResultBits = 1 << 20
+
+func kindOf(value interface{}) (numberKind, bool) {
+	switch value.(type) {
+	case int64:
+		return INT_KIND, true
+	case *big.Int:
+		return BIG_KIND, true
+	case *decimal.Decimal:
+		return DECIMAL_KIND, true
+	case float64:
+		return FLOAT_KIND, true
+	}
+
+	return 0, false
+}
+
+func isNumber(value interface{}) bool {
+	_, ok := kindOf(value)
+
+	return ok
+}
+
+func widerKind(left, right interface{}) numberKind {
+	leftKind, _ := kindOf(left)
+	rightKind, _ := kindOf(right)
+
+	return max(leftKind, rightKind)
+}
+
+func toFloat(value interface{}) float64 {
+	switch num := value.(type) {
+	case int64:
+		return float64(num)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(num).Float64()
+		return f
+	case *decimal.Decimal:
+		return num.Float64()
+	case float64:
+		return num
+	}
+
+	return 0
+}
+
+func toBig(value interface{}) *big.Int {
+	switch num := value.(type) {
+	case int64:
+		return big.NewInt(num)
+	case *big.Int:
+		return num
+	}
+
+	return nil
+}
+
+func toDecimal(value interface{}) *decimal.Decimal {
+	switch num := value.(type) {
+	case int64:
+		return decimal.FromInt(big.NewInt(num))
+	case *big.Int:
+		return decimal.FromInt(num)
+	case *decimal.Decimal:
+		return num
+	}
+
+	return nil
+}
+
+// toInteger accepts any number with no fractional part that fits an int64,
+// for places such as list indices that need a whole number.
+func toInteger(value interface{}) (int64, bool) {
+	switch num := value.(type) {
+	case int64:
+		return num, true
+	case *big.Int:
+		if num.IsInt64() {
+			return num.Int64(), true
+		}
+	case *decimal.Decimal:
+		if integer := num.Int(); num.IsInt() && integer.IsInt64() {
+			return integer.Int64(), true
+		}
+	case float64:
+		if num == math.Trunc(num) && num >= math.MinInt64 && num < math.MaxInt64 {
+			return int64(num), true
+		}
+	}
+
+	return 0, false
+}
+
+// truncate coerces a number to an int64 for the bitwise operators.
+func truncate(value interface{}) int64 {
+	switch num := value.(type) {
+	case int64:
+		return num
+	case *big.Int:
+		return num.Int64()
+	case *decimal.Decimal:
+		return num.Int().Int64()
+	}
+
+	return int64(toFloat(value))
+}
+
+// compare orders two numbers, returning -1, 0 or 1. Comparisons involving a
+// float are made between floats, and everything else compares exactly.
+func compare(left, right interface{}) int {
+	switch widerKind(left, right) {
+	case INT_KIND:
+		leftInt, rightInt := left.(int64), right.(int64)
+		if leftInt < rightInt {
+			return -1
+		} else if leftInt > rightInt {
+			return 1
+		}
+
+		return 0
+	case BIG_KIND:
+		return toBig(left).Cmp(toBig(right))
+	case DECIMAL_KIND:
+		return toDecimal(left).Cmp(toDecimal(right))
+	}
+
+	leftNum, rightNum := toFloat(left), toFloat(right)
+	if leftNum < rightNum {
+		return -1
+	} else if leftNum > rightNum {
+		return 1
+	} else if leftNum == rightNum {
+		return 0
+	}
+
+	// NaN is unordered, so report it as different from everything
+	return 2
+}
+
+func compareNumbers(operator token.Token, left, right interface{}) bool {
+	result := compare(left, right)
+	if result == 2 {
+		return false
+	}
+
+	switch operator.Type {
+	case token.GREATER:
+		return result > 0
+	case token.GREATER_EQUAL:
+		return result >= 0
+	case token.LESS:
+		return result < 0
+	}
+
+	return result <= 0
+}
+
+// arithmetic applies + - * / % or ** to two numbers in the wider of their
+// kinds. Integer results that overflow an int64 are promoted to BigInts.
+func arithmetic(operator token.Token, left, right interface{}) (interface{}, error) {
+	switch widerKind(left, right) {
+	case INT_KIND:
+		return integerArithmetic(operator, left.(int64), right.(int64))
+	case BIG_KIND:
+		return bigArithmetic(operator, toBig(left), toBig(right))
+	case DECIMAL_KIND:
+		return decimalArithmetic(operator, toDecimal(left), toDecimal(right))
+	}
+
+	leftNum, rightNum := toFloat(left), toFloat(right)
+	switch operator.Type {
+	case token.PLUS:
+		return leftNum + rightNum, nil
+	case token.MINUS:
+		return leftNum - rightNum, nil
+	case token.STAR:
+		return leftNum * rightNum, nil
+	case token.SLASH:
+		return leftNum / rightNum, nil
+	case token.PERCENT:
+		return math.Mod(leftNum, rightNum), nil
+	}
+
+	return math.Pow(leftNum, rightNum), nil
+}
+
+func integerArithmetic(operator token.Token, left, right int64) (interface{}, error) {
+	promoted := func() (interface{}, error) {
+		return bigArithmetic(operator, big.NewInt(left), big.NewInt(right))
+	}
+
+	switch operator.Type {
+	case token.PLUS:
+		result := left + right
+		if (left^result)&(right^result) < 0 {
+			return promoted()
+		}
+
+		return result, nil
+	case token.MINUS:
+		result := left - right
+		if (left^right)&(left^result) < 0 {
+			return promoted()
+		}
+
+		return result, nil
+	case token.STAR:
+		result, ok := multiply(left, right)
+		if !ok {
+			return promoted()
+		}
+
+		return result, nil
+	case token.SLASH:
+		if right == 0 {
+			return nil, divisionByZero(operator)
+		}
+
+		if left == math.MinInt64 && right == -1 {
+			return promoted()
+		}
+
+		return left / right, nil
+	case token.PERCENT:
+		if right == 0 {
+			return nil, divisionByZero(operator)
+		}
+
+		return left % right, nil
+	}
+
+	// a negative exponent can't give an integer result
+	if right < 0 {
+		return math.Pow(float64(left), float64(right)), nil
+	}
+
+	result := int64(1)
+	for base, exponent := left, right; exponent > 0; exponent >>= 1 {
+		var ok bool
+		if exponent&1 == 1 {
+			if result, ok = multiply(result, base); !ok {
+				return promoted()
+			}
+		}
+
+		if exponent > 1 {
+			if base, ok = multiply(base, base); !ok {
+				return promoted()
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func multiply(left, right int64) (int64, bool) {
+	if left == 0 || right == 0 {
+		return 0, true
+	}
+
+	result := left * right
+	if result/right != left || (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64) {
+		return 0, false
+	}
+
+	return result, true
+}
+
+func bigArithmetic(operator token.Token, left, right *big.Int) (interface{}, error) {
+	switch operator.Type {
+	case token.PLUS:
+		return new(big.Int).Add(left, right), nil
+	case token.MINUS:
+		return new(big.Int).Sub(left, right), nil
+	case token.STAR:
+		if left.BitLen()+right.BitLen() > maxResultBits {
+			return nil, resultTooLarge(operator)
+		}
+
+		return new(big.Int).Mul(left, right), nil
+	case token.SLASH:
+		if right.Sign() == 0 {
+			return nil, divisionByZero(operator)
+		}
+
+		return new(big.Int).Quo(left, right), nil
+	case token.PERCENT:
+		if right.Sign() == 0 {
+			return nil, divisionByZero(operator)
+		}
+
+		return new(big.Int).Rem(left, right), nil
+	}
+
+	if right.Sign() < 0 {
+		return math.Pow(toFloat(left), toFloat(right)), nil
+	}
+
+	// 0, 1 and -1 stay small whatever the power
+	if left.CmpAbs(big.NewInt(1)) <= 0 {
+		return new(big.Int).Exp(left, right, nil), nil
+	}
+
+	if !right.IsInt64() {
+		return nil, &RuntimeError{Token: operator, Msg: "Exponent is too large."}
+	}
+
+	if powerTooLarge(int64(left.BitLen()-1), right.Int64()) {
+		return nil, resultTooLarge(operator)
+	}
+
+	return new(big.Int).Exp(left, right, nil), nil
+}
+
+func decimalArithmetic(operator token.Token, left, right *decimal.Decimal) (interface{}, error) {
+	switch operator.Type {
+	case token.PLUS:
+		return left.Add(right), nil
+	case token.MINUS:
+		return left.Sub(right), nil
+	case token.STAR:
+		if decimalBits(left)+decimalBits(right) > maxResultBits {
+			return nil, resultTooLarge(operator)
+		}
+
+		return left.Mul(right), nil
+	case token.SLASH:
+		result, ok := left.Quo(right)
+		if !ok {
+			return nil, divisionByZero(operator)
+		}
+
+		return result, nil
+	case token.PERCENT:
+		result, ok := left.Rem(right)
+		if !ok {
+			return nil, divisionByZero(operator)
+		}
+
+		return result, nil
+	}
+
+	// only whole powers of a decimal are exact
+	exponent := right.Int()
+	if !right.IsInt() || !exponent.IsInt64() {
+		return math.Pow(left.Float64(), right.Float64()), nil
+	}
+
+	if powerTooLarge(int64(decimalBits(left)), exponent.Int64()) {
+		return nil, resultTooLarge(operator)
+	}
+
+	result, ok := left.Pow(exponent.Int64())
+	if !ok {
+		return nil, divisionByZero(operator)
+	}
+
+	return result, nil
+}
+
+func negate(value interface{}) interface{} {
+	switch num := value.(type) {
+	case int64:
+		if num == math.MinInt64 {
+			return new(big.Int).Neg(big.NewInt(num))
+		}
+
+		return -num
+	case *big.Int:
+		return new(big.Int).Neg(num)
+	case *decimal.Decimal:
+		return num.Neg()
+	}
+
+	return -toFloat(value)
+}
+
+// bitwise applies an integer operator. BigInt operands keep full precision,
+// and anything else is truncated to an int64 first.
+func bitwise(operator token.Token, left, right interface{}) (interface{}, error) {
+	_, leftIsBig := left.(*big.Int)
+	_, rightIsBig := right.(*big.Int)
+	if leftIsBig || rightIsBig {
+		return bigBitwise(operator, bigInteger(left), bigInteger(right))
+	}
+
+	leftInt, rightInt := truncate(left), truncate(right)
+	switch operator.Type {
+	case token.AMPERSAND:
+		return leftInt & rightInt, nil
+	case token.PIPE:
+		return leftInt | rightInt, nil
+	case token.CARET:
+		return leftInt ^ rightInt, nil
+	}
+
+	if rightInt < 0 {
+		return nil, &RuntimeError{Token: operator, Msg: "Shift count can't be negative."}
+	}
+
+	if operator.Type == token.LESS_LESS {
+		// bits shifted out of an int64 promote the result instead of being lost
+		if rightInt >= 63 || (leftInt<<rightInt)>>rightInt != leftInt {
+			return bigBitwise(operator, big.NewInt(leftInt), big.NewInt(rightInt))
+		}
+
+		return leftInt << rightInt, nil
+	}
+
+	if rightInt >= 63 {
+		rightInt = 63
+	}
+
+	return leftInt >> rightInt, nil
+}
+
+func bigInteger(value interface{}) *big.Int {
+	if num, ok := value.(*big.Int); ok {
+		return num
+	}
+
+	return big.NewInt(truncate(value))
+}
+
+func bigBitwise(operator token.Token, left, right *big.Int) (interface{}, error) {
+	switch operator.Type {
+	case token.AMPERSAND:
+		return new(big.Int).And(left, right), nil
+	case token.PIPE:
+		return new(big.Int).Or(left, right), nil
+	case token.CARET:
+		return new(big.Int).Xor(left, right), nil
+	}
+
+	if right.Sign() < 0 {
+		return nil, &RuntimeError{Token: operator, Msg: "Shift count can't be negative."}
+	}
+
+	if !right.IsInt64() || right.Int64() > math.MaxInt32 {
+		return nil, &RuntimeError{Token: operator, Msg: "Shift count is too large."}
+	}
+
+	if operator.Type == token.LESS_LESS {
+		if left.Sign() != 0 && int64(left.BitLen())+right.Int64() > maxResultBits {
+			return nil, resultTooLarge(operator)
+		}
+
+		return new(big.Int).Lsh(left, uint(right.Int64())), nil
+	}
+
+	return new(big.Int).Rsh(left, uint(right.Int64())), nil
+}
+
+func complement(value interface{}) interface{} {
+	if num, ok := value.(*big.Int); ok {
+		return new(big.Int).Not(num)
+	}
+
+	return ^truncate(value)
+}
+
+// powerTooLarge reports whether raising a number of about bits bits to
+// exponent goes past maxResultBits.
+func powerTooLarge(bits, exponent int64) bool {
+	if exponent < 0 {
+		exponent = -exponent
+	}
+
+	return bits > 0 && exponent > maxResultBits/bits
+}
+
+// decimalBits estimates the bits a decimal's digits take up, counting the
+// digits its scale prints as well as its value, since 1.0d ** n prints n
+// zeros.
+func decimalBits(d *decimal.Decimal) int {
+	rat := d.Rat()
+
+	return max(rat.Num().BitLen(), rat.Denom().BitLen()) - 1 + d.Scale()*4
+}
+
+func resultTooLarge(operator token.Token) error {
+	return &RuntimeError{Token: operator, Msg: "Result is too large."}
+}
+
+func divisionByZero(operator token.Token) error {
+	return &RuntimeError{Token: operator, Msg: "Division by zero."}
+}
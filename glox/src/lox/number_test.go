@@ -0,0 +1,78 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIntegers(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "integer division", source: "print 7 / 2; print -7 / 2; print 7 % -3;", want: "3\n-3\n1\n"},
+		{name: "promotion to float", source: "print 7 / 2.0; print 1 + 0.5; print 2 ** -1;", want: "3.5\n1.5\n0.5\n"},
+		{name: "mixed comparison", source: "print 1 == 1.0; print 2 > 1.5; print 3 <= 3.0;", want: "true\ntrue\ntrue\n"},
+		{name: "precision", source: "print 9007199254740993; print 9007199254740993 + 1;", want: "9007199254740993\n9007199254740994\n"},
+		{
+			name:   "radix literals",
+			source: "print 0xFF; print 0b1010; print 0o17; print 1_000_000; print 0xdead_beef;",
+			want:   "255\n10\n15\n1000000\n3735928559\n",
+		},
+		{name: "scientific notation", source: "print 1e3; print 2.5e-1; print 1_0.5;", want: "1000\n0.25\n10.5\n"},
+		{name: "integer keys match floats", source: "var m = {1: \"one\"}; print m[1.0]; print [1, 2][1.0];", want: "one\n2\n"},
+		{name: "overflow promotes to BigInt", source: "print 9223372036854775807 + 1;", want: "9223372036854775808\n"},
+		{name: "multiplication overflow", source: "print 4611686018427387904 * 2;", want: "9223372036854775808\n"},
+		{name: "exponent overflow", source: "print 2 ** 64;", want: "18446744073709551616\n"},
+		{name: "negation overflow", source: "var n = -9223372036854775807 - 1; print -n;", want: "9223372036854775808\n"},
+		{name: "division by zero", source: "print 1 / 0;", err: "Division by zero."},
+		{name: "modulo by zero", source: "print 1 % 0;", err: "Division by zero."},
+		{name: "float division by zero", source: "print 1.0 / 0;", want: "+Inf\n"},
+		{name: "large literals", source: "print 9223372036854775808;", want: "9223372036854775808\n"},
+		{name: "invalid hex", source: "print 0x;", err: "Invalid hexadecimal literal."},
+	})
+}
+
+func TestBigNumbers(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "literals", source: "print 123n; print 0xFFn; print 1.10d; print -5n;", want: "123\n255\n1.10\n-5\n"},
+		{name: "exact decimals", source: "print 0.1d + 0.2d; print 0.1 + 0.2;", want: "0.3\n0.30000000000000004\n"},
+		{name: "decimal scale", source: "print 1.5d * 2; print 1d / 3d;", want: "3.0\n0.3333333333333333333333333333333333\n"},
+		{
+			name:   "very small decimals",
+			source: "print 1.5e-40d; print 1e-19d * 1e-19d; print 1e-40d + 1d; print 1d / 2e40d; print 1e-40d / 3d;",
+			want: "0.00000000000000000000000000000000000000015\n" +
+				"0.00000000000000000000000000000000000001\n" +
+				"1.0000000000000000000000000000000000000001\n" +
+				"0.00000000000000000000000000000000000000005\n" +
+				"0.00000000000000000000000000000000000000003333333333333333333333333333333333\n",
+		},
+		{name: "BigInt arithmetic", source: "print 2n ** 100n; print 10n / 4n; print 7n % 3n;", want: "1267650600228229401496703205376\n2\n1\n"},
+		{name: "promotion", source: "print 1n + 1; print 0.5d + 0.5; print 1n << 70;", want: "2\n1\n1180591620717411303424\n"},
+		{name: "equality across kinds", source: "print 1n == 1; print 1.0d == 1; print 2n > 1.5;", want: "true\ntrue\ntrue\n"},
+		{name: "map keys across kinds", source: `var m = {1n: "big"}; print m[1]; print m[1.0d];`, want: "big\nbig\n"},
+		{
+			name:   "conversions",
+			source: `print int("42"); print int(3.9); print float(1n); print bigint(7); print decimal(0.1); print decimal("1.25"); print int(99999999999999999999d);`,
+			want:   "42\n3\n1\n7\n0.1\n1.25\n99999999999999999999\n",
+		},
+		{name: "shifts promote", source: "print 1 << 64; print (1 << 64) >> 63; print -1n >> 1;", want: "18446744073709551616\n2\n-1\n"},
+		{name: "huge shift", source: "print 1 << 3000000000;", err: "Shift count is too large."},
+		{name: "shift result too large", source: "print 1 << 2000000000;", err: "Result is too large."},
+		{name: "power too large", source: "print 2 ** 4000000000;", err: "Result is too large."},
+		{name: "BigInt product too large", source: "var n = 1n << 1000000; print n * n;", err: "Result is too large."},
+		{name: "decimal power too large", source: "print 1.0d ** 4000000000;", err: "Result is too large."},
+		{name: "powers of one stay small", source: "print 1 ** 4000000000; print -1n ** 4000000001;", want: "1\n-1\n"},
+		{name: "invalid conversion", source: `print int("4x");`, err: "Can't convert '4x' to an integer."},
+		{name: "BigInt division by zero", source: "print 1n / 0n;", err: "Division by zero."},
+		{name: "decimal division by zero", source: "print 1d / 0;", err: "Division by zero."},
+		{name: "fractional BigInt", source: "print 1.5n;", err: "BigInt literal '1.5' can't have a fraction or exponent."},
+		{name: "invalid decimal", source: "print 1e-999999999d;", err: "Invalid decimal literal '1e-999999999'."},
+	})
+}
+
+func TestInvalidNumbersReportedOnce(t *testing.T) {
+	for _, source := range []string{"print 1e-999999999d;", "print 1.5n;", "print 0x;"} {
+		_, errors := run(t, source)
+		if lines := strings.Count(errors, "\n"); lines != 1 {
+			t.Errorf("%s reported %d errors, want 1:\n%s", source, lines, errors)
+		}
+	}
+}
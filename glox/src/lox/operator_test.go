@@ -0,0 +1,67 @@
+package lox
+
+import "testing"
+
+func TestOperators(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "ternary", source: "print true ? 1 : 2; print nil ? 1 : 2;", want: "1\n2\n"},
+		{name: "nested ternary", source: "print false ? 1 : false ? 2 : 3; print true ? false ? 1 : 2 : 3;", want: "3\n2\n"},
+		{
+			name:   "ternary evaluates one branch",
+			source: `fun loud(v) { print v; return v; } print true ? loud("then") : loud("else");`,
+			want:   "then\nthen\n",
+		},
+		{name: "modulo", source: "print 7 % 3; print 5.5 % 2;", want: "1\n1.5\n"},
+		{name: "exponent", source: "print 2 ** 10; print 2 ** 3 ** 2; print -2 ** 2;", want: "1024\n512\n-4\n"},
+		{name: "bitwise", source: "print 6 & 3; print 6 | 3; print 6 ^ 3; print ~5;", want: "2\n7\n5\n-6\n"},
+		{name: "shifts", source: "print 1 << 4; print -16 >> 2;", want: "16\n-4\n"},
+		{
+			name:   "precedence",
+			source: "print 1 + 2 << 1; print 1 | 2 & 3; print 2 * 3 % 4; print 1 < 2 == true;",
+			want:   "6\n3\n2\ntrue\n",
+		},
+		{name: "negative shift", source: "print 1 << -1;", err: "Shift count can't be negative."},
+		{name: "bitwise on strings", source: `print "a" & 1;`, err: "Operands must be numbers."},
+		{name: "missing colon", source: "print true ? 1;", err: "Expect ':' after then branch of conditional expression."},
+	})
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name:   "variables",
+			source: "var a = 10; a += 5; a -= 3; a *= 2; a /= 4; a %= 4; print a;",
+			want:   "15\n12\n24\n6\n2\n2\n",
+		},
+		{name: "strings", source: `var s = "a"; s += "b"; print s;`, want: "ab\nab\n"},
+		{
+			name:   "fields and indexes",
+			source: "class Box {} var b = Box(); b.n = 1; b.n += 1; var l = [1, 2]; l[1] *= 10; print b.n; print l;",
+			want:   "1\n2\n20\n2\n[1, 20]\n",
+		},
+		{
+			name:   "targets are evaluated once",
+			source: `var l = [0, 0]; var i = 0; fun next() { i += 1; return l; } next()[1] += 5; print i; print l;`,
+			want:   "5\n1\n[0, 5]\n",
+		},
+		{
+			name:   "increments",
+			source: "var a = 1; print a++; print a; print ++a; print a--; print --a;",
+			want:   "1\n2\n3\n3\n1\n",
+		},
+		{
+			name:   "increment fields and indexes",
+			source: "class C {} var c = C(); c.n = 0; c.n++; var l = [5]; ++l[0]; print c.n; print l[0];",
+			want:   "0\n0\n6\n1\n6\n",
+		},
+		{
+			name:   "in loops",
+			source: "var total = 0; for (var i = 0; i < 4; i++) { total += i; } print total;",
+			want:   "6\n",
+		},
+		{name: "mismatched operands", source: `var a = "a"; a += 1;`, err: "Operands must be two numbers or two strings."},
+		{name: "invalid target", source: "1 += 2;", err: "Invalid assignment target."},
+		{name: "invalid increment", source: "(1)++;", err: "Invalid increment target."},
+		{name: "increment a string", source: `var s = "a"; s++;`, err: "Operand must be a number."},
+	})
+}
@@ -1,15 +1,19 @@
 package lox
 
 import (
-	"fmt"
 	"github.com/dmcg310/glox/src/ast"
 	"github.com/dmcg310/glox/src/token"
 )
 
 type Parser struct {
-	Tokens  []token.Token
-	Current int
-	Lox     *Lox
+	Tokens    []token.Token
+	Current   int
+	Lox       *Lox
+	loopDepth int
+
+	// set while parsing a match guard, where "(x) =>" ends the guard rather
+	// than starting an arrow lambda
+	inGuard bool
 }
 
 func NewParser(tokens []token.Token, lox *Lox) Parser {
@@ -23,12 +27,14 @@ func NewParser(tokens []token.Token, lox *Lox) Parser {
 func (p *Parser) Parse() []ast.Stmt {
 	statements := []ast.Stmt{}
 	for !p.isAtEnd() {
+		start := p.peek()
 		stmt, err := p.declaration()
 		if err != nil {
 			p.synchronise()
 			continue
 		}
 
+		p.Lox.Interpreter.markStart(stmt, start)
 		statements = append(statements, stmt)
 	}
 
@@ -40,14 +46,130 @@ func (p *Parser) expression() (ast.Expr, error) {
 }
 
 func (p *Parser) declaration() (ast.Stmt, error) {
-	if p.match(token.VAR) {
+	if p.match(token.CLASS) {
+		return p.classDeclaration()
+	}
+
+	// "fun" not followed by a name starts an anonymous function expression
+	if p.check(token.FUN) && p.checkNext(token.IDENTIFIER) {
+		p.advance()
+		return p.function("function")
+	}
+
+	if p.match(token.IMPORT) {
+		return p.importDeclaration()
+	}
+
+	if p.match(token.VAR, token.LET, token.CONST) {
 		return p.varDeclaration()
 	}
 
 	return p.statement()
 }
 
+func (p *Parser) classDeclaration() (ast.Stmt, error) {
+	name, err := p.consume(token.IDENTIFIER, "Expect class name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *ast.Variable
+	if p.match(token.LESS) {
+		superName, err := p.consume(token.IDENTIFIER, "Expect superclass name.")
+		if err != nil {
+			return nil, err
+		}
+
+		superclass = &ast.Variable{Name: superName}
+	}
+
+	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before class body.")
+	if err != nil {
+		return nil, err
+	}
+
+	methods := []*ast.Function{}
+	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+
+		methods = append(methods, method)
+	}
+
+	_, err = p.consume(token.RIGHT_BRACE, "Expect '}' after class body.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Class{Name: name, Superclass: superclass, Methods: methods}, nil
+}
+
+func (p *Parser) importDeclaration() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	var names []token.Token
+	if p.match(token.LEFT_BRACE) {
+		for {
+			name, err := p.consume(token.IDENTIFIER, "Expect name to import.")
+			if err != nil {
+				return nil, err
+			}
+
+			names = append(names, name)
+
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+
+		_, err := p.consume(token.RIGHT_BRACE, "Expect '}' after imported names.")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.consumeWord("from", "Expect 'from' after imported names.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	path, err := p.consume(token.STRING, "Expect module path.")
+	if err != nil {
+		return nil, err
+	}
+
+	var alias token.Token
+	if names == nil {
+		_, err = p.consumeWord("as", "Expect 'as' after module path.")
+		if err != nil {
+			return nil, err
+		}
+
+		alias, err = p.consume(token.IDENTIFIER, "Expect module name after 'as'.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after import.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Import{Keyword: keyword, Path: path, Alias: alias, Names: names}, nil
+}
+
 func (p *Parser) statement() (ast.Stmt, error) {
+	if p.match(token.BREAK) {
+		return p.breakStatement()
+	}
+
+	if p.match(token.CONTINUE) {
+		return p.continueStatement()
+	}
+
 	if p.match(token.FOR) {
 		return p.forStatement()
 	}
@@ -56,10 +178,27 @@ func (p *Parser) statement() (ast.Stmt, error) {
 		return p.ifStatement()
 	}
 
+	if p.isMatch() {
+		p.advance()
+		return p.matchStatement()
+	}
+
 	if p.match(token.PRINT) {
 		return p.printStatement()
 	}
 
+	if p.match(token.RETURN) {
+		return p.returnStatement()
+	}
+
+	if p.match(token.THROW) {
+		return p.throwStatement()
+	}
+
+	if p.match(token.TRY) {
+		return p.tryStatement()
+	}
+
 	if p.match(token.WHILE) {
 		return p.whileStatement()
 	}
@@ -79,6 +218,8 @@ func (p *Parser) statement() (ast.Stmt, error) {
 }
 
 func (p *Parser) forStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+
 	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after 'for'.")
 	if err != nil {
 		return nil, err
@@ -87,13 +228,13 @@ func (p *Parser) forStatement() (ast.Stmt, error) {
 	var initialiser ast.Stmt
 	if p.match(token.SEMICOLON) {
 		initialiser = nil
-	} else if p.match(token.VAR) {
+	} else if p.match(token.VAR, token.LET, token.CONST) {
 		initialiser, err = p.varDeclaration()
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		initialiser, err = p.expression()
+		initialiser, err = p.expressionStatement()
 		if err != nil {
 			return nil, err
 		}
@@ -106,7 +247,7 @@ func (p *Parser) forStatement() (ast.Stmt, error) {
 			return nil, err
 		}
 	}
-	_, err = p.consume(token.RIGHT_PAREN, "Expect ';' after loop condition.")
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after loop condition.")
 	if err != nil {
 		return nil, err
 	}
@@ -124,30 +265,24 @@ func (p *Parser) forStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	body, err := p.statement()
+	body, err := p.loopBody()
 	if err != nil {
 		return nil, err
 	}
 
-	if increment != nil {
-		body = &ast.Block{
-			Statements: []ast.Stmt{
-				body, &ast.Expression{
-					Expression: increment,
-				},
-			},
-		}
-	}
-
 	if condition == nil {
 		condition = &ast.Literal{
 			Value: true,
 		}
 	}
 
+	// the increment is kept on the loop rather than appended to the body so
+	// that a continue inside the body still runs it
 	body = &ast.While{
+		Keyword:   keyword,
 		Condition: condition,
 		Body:      body,
+		Increment: increment,
 	}
 
 	if initialiser != nil {
@@ -208,202 +343,1034 @@ func (p *Parser) printStatement() (ast.Stmt, error) {
 	return &ast.Print{Expression: value}, nil
 }
 
-func (p *Parser) varDeclaration() (ast.Stmt, error) {
-	name, err := p.consume(token.IDENTIFIER, "Expect variable name.")
-	if err != nil {
-		return nil, err
-	}
+func (p *Parser) returnStatement() (ast.Stmt, error) {
+	keyword := p.previous()
 
-	var initialiser ast.Expr
-	if p.match(token.EQUAL) {
-		initialiser, err = p.expression()
+	var value ast.Expr
+	var err error
+	if !p.check(token.SEMICOLON) {
+		value, err = p.expression()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	_, err = p.consume(token.SEMICOLON, "Expect ';' after variable declaration.")
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after return value.")
 	if err != nil {
 		return nil, err
 	}
 
-	return &ast.Var{Name: name, Initialiser: initialiser}, nil
+	return &ast.Return{Keyword: keyword, Value: value}, nil
 }
 
-func (p *Parser) whileStatement() (ast.Stmt, error) {
-	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after 'while'.")
+func (p *Parser) throwStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	value, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
 
-	condition, err := p.expression()
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after thrown value.")
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = p.consume(token.RIGHT_PAREN, "Expect ')' after condition.")
+	return &ast.Throw{Keyword: keyword, Value: value}, nil
+}
+
+func (p *Parser) tryStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	body, err := p.blockStatement("try")
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := p.statement()
-	if err != nil {
-		return nil, err
+	var catchName token.Token
+	var catchBody *ast.Block
+	if p.match(token.CATCH) {
+		_, err = p.consume(token.LEFT_PAREN, "Expect '(' after 'catch'.")
+		if err != nil {
+			return nil, err
+		}
+
+		catchName, err = p.consume(token.IDENTIFIER, "Expect exception variable name.")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.consume(token.RIGHT_PAREN, "Expect ')' after exception variable.")
+		if err != nil {
+			return nil, err
+		}
+
+		catchBody, err = p.blockStatement("catch")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var finallyBody *ast.Block
+	if p.match(token.FINALLY) {
+		finallyBody, err = p.blockStatement("finally")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if catchBody == nil && finallyBody == nil {
+		return nil, p.error(p.peek(), "Expect 'catch' or 'finally' after try block.")
 	}
 
-	return &ast.While{Condition: condition, Body: body}, nil
+	return &ast.Try{
+		Keyword:     keyword,
+		Body:        body,
+		CatchName:   catchName,
+		CatchBody:   catchBody,
+		FinallyBody: finallyBody,
+	}, nil
 }
 
-func (p *Parser) expressionStatement() (ast.Stmt, error) {
-	expr, err := p.expression()
+// matchStatement parses a match in statement position, where an arm's body
+// may be a block or a statement as well as an expression.
+func (p *Parser) matchStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	subject, arms, err := p.matchBody(true)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = p.consume(token.SEMICOLON, "Expect ';' after expression.")
+	return &ast.MatchStmt{Keyword: keyword, Subject: subject, Arms: arms}, nil
+}
+
+func (p *Parser) matchExpression() (ast.Expr, error) {
+	keyword := p.previous()
+
+	subject, arms, err := p.matchBody(false)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ast.Expression{Expression: expr}, nil
+	return &ast.Match{Keyword: keyword, Subject: subject, Arms: arms}, nil
 }
 
-func (p *Parser) block() ([]ast.Stmt, error) {
-	statements := make([]ast.Stmt, 10)
+// matchBody parses "(subject) { arm, arm, ... }". In a match statement the
+// commas between arms are optional.
+func (p *Parser) matchBody(statement bool) (ast.Expr, []*ast.MatchArm, error) {
+	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after 'match'.")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subject, err := p.expression()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = p.consume(token.RIGHT_PAREN, "Expect ')' after match subject.")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before match arms.")
+	if err != nil {
+		return nil, nil, err
+	}
 
+	arms := []*ast.MatchArm{}
 	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
-		res, err := p.declaration()
+		arm, err := p.matchArm(statement)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		statements = append(statements, res)
+		arms = append(arms, arm)
+
+		if !p.match(token.COMMA) && !statement {
+			break
+		}
 	}
 
-	_, err := p.consume(token.RIGHT_BRACE, "Expect '}' after block.")
+	_, err = p.consume(token.RIGHT_BRACE, "Expect '}' after match arms.")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return statements, nil
+	return subject, arms, nil
 }
 
-func (p *Parser) assignment() (ast.Expr, error) {
-	expr, err := p.or()
-	if err != nil {
-		return nil, err
-	}
+func (p *Parser) matchArm(statement bool) (*ast.MatchArm, error) {
+	var keyword token.Token
+	var pattern ast.Pattern
+	var err error
 
-	if p.match(token.EQUAL) {
-		equals := p.previous()
-		val, err := p.assignment()
+	// a bare "_" is shorthand for "case _"
+	if p.checkWord("_") {
+		keyword = p.advance()
+		pattern = &ast.WildcardPattern{Token: keyword}
+	} else {
+		keyword, err = p.consumeWord("case", "Expect 'case' or '_' before match arm.")
 		if err != nil {
 			return nil, err
 		}
 
-		varExpr, ok := expr.(*ast.Variable)
-		if ok {
-			name := varExpr.Name
-
-			return &ast.Assign{
-				Name:  name,
-				Value: val,
-			}, nil
+		pattern, err = p.pattern()
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		err = p.error(equals, "Invalid assignment target.")
+	var guard ast.Expr
+	if p.match(token.IF) {
+		enclosingGuard := p.inGuard
+		p.inGuard = true
+		guard, err = p.expression()
+		p.inGuard = enclosingGuard
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return expr, nil
-}
-
-func (p *Parser) or() (ast.Expr, error) {
-	expr, err := p.and()
+	_, err = p.consume(token.ARROW, "Expect '=>' after match pattern.")
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(token.OR) {
-		operator := p.previous()
-		right, err := p.and()
+	var body ast.Stmt
+	if statement && p.startsStatement() {
+		body, err = p.statement()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		value, err := p.expression()
 		if err != nil {
 			return nil, err
 		}
 
-		expr = &ast.Logical{Left: expr, Operator: operator, Right: right}
+		// an expression arm in a statement may end like a statement does
+		if statement {
+			p.match(token.SEMICOLON)
+		}
+
+		body = &ast.Expression{Expression: value}
 	}
 
-	return expr, nil
+	return &ast.MatchArm{Keyword: keyword, Pattern: pattern, Guard: guard, Body: body}, nil
 }
 
-func (p *Parser) and() (ast.Expr, error) {
-	expr, err := p.equality()
+// startsStatement reports whether the next token begins a statement that
+// isn't an expression statement.
+func (p *Parser) startsStatement() bool {
+	switch p.peek().Type {
+	case token.BREAK, token.CONTINUE, token.FOR, token.IF, token.PRINT,
+		token.RETURN, token.THROW, token.TRY, token.WHILE, token.LEFT_BRACE:
+		return true
+	}
+
+	return p.isMatch()
+}
+
+// pattern parses a pattern and any alternatives to it separated by '|'.
+func (p *Parser) pattern() (ast.Pattern, error) {
+	first, err := p.primaryPattern()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(token.AND) {
-		operator := p.previous()
-		right, err := p.equality()
+	if !p.check(token.PIPE) {
+		return first, nil
+	}
+
+	alternatives := []ast.Pattern{first}
+	for p.match(token.PIPE) {
+		alternative, err := p.primaryPattern()
 		if err != nil {
 			return nil, err
 		}
 
-		expr = &ast.Logical{Left: expr, Operator: operator, Right: right}
+		alternatives = append(alternatives, alternative)
 	}
 
-	return expr, nil
+	return &ast.AlternativePattern{Alternatives: alternatives}, nil
 }
 
-func (p *Parser) equality() (ast.Expr, error) {
-	expr, err := p.comparison()
-	if err != nil {
-		return nil, err
+func (p *Parser) primaryPattern() (ast.Pattern, error) {
+	if p.match(token.FALSE) {
+		return &ast.LiteralPattern{Token: p.previous(), Value: false}, nil
 	}
 
-	for p.match(token.BANG_EQUAL, token.EQUAL_EQUAL) {
-		operator := p.previous()
-		right, err := p.comparison()
+	if p.match(token.TRUE) {
+		return &ast.LiteralPattern{Token: p.previous(), Value: true}, nil
+	}
+
+	if p.match(token.NIL) {
+		return &ast.LiteralPattern{Token: p.previous(), Value: nil}, nil
+	}
+
+	if p.match(token.NUMBER, token.STRING) {
+		return &ast.LiteralPattern{Token: p.previous(), Value: p.previous().Literal}, nil
+	}
+
+	if p.match(token.MINUS) {
+		number, err := p.consume(token.NUMBER, "Expect number after '-' in pattern.")
 		if err != nil {
 			return nil, err
 		}
 
-		expr = &ast.Binary{
-			Left:     expr,
-			Operator: operator,
-			Right:    right,
+		return &ast.LiteralPattern{Token: number, Value: negate(number.Literal)}, nil
+	}
+
+	if p.match(token.IDENTIFIER) {
+		name := p.previous()
+		if name.Lexeme == "_" {
+			return &ast.WildcardPattern{Token: name}, nil
+		}
+
+		if p.match(token.LEFT_BRACE) {
+			return p.classPattern(name)
 		}
+
+		return &ast.BindingPattern{Name: name}, nil
 	}
 
-	return expr, nil
-}
+	if p.match(token.LEFT_BRACKET) {
+		return p.listPattern(p.pattern)
+	}
 
-func (p *Parser) comparison() (ast.Expr, error) {
-	expr, err := p.term()
-	if err != nil {
-		return nil, err
+	if p.match(token.LEFT_BRACE) {
+		return p.objectPattern(p.pattern)
 	}
 
-	for p.match(token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL) {
-		operator := p.previous()
-		right, err := p.term()
+	return nil, p.error(p.peek(), "Expect pattern.")
+}
+
+// listPattern parses "[a, b, ...rest]" once the '[' has been consumed, using
+// element for each element. The rest pattern is optional, and may be "...",
+// "..._" or "...name".
+func (p *Parser) listPattern(element func() (ast.Pattern, error)) (ast.Pattern, error) {
+	bracket := p.previous()
+	elements := []ast.Pattern{}
+	hasRest := false
+	var rest ast.Pattern
+
+	for !p.check(token.RIGHT_BRACKET) {
+		if p.match(token.ELLIPSIS) {
+			hasRest = true
+			if p.match(token.IDENTIFIER) {
+				rest = &ast.BindingPattern{Name: p.previous()}
+				if p.previous().Lexeme == "_" {
+					rest = &ast.WildcardPattern{Token: p.previous()}
+				}
+			}
+
+			break
+		}
+
+		pattern, err := element()
 		if err != nil {
 			return nil, err
 		}
 
-		expr = &ast.Binary{
-			Left:     expr,
-			Operator: operator,
-			Right:    right,
+		elements = append(elements, pattern)
+
+		if !p.match(token.COMMA) {
+			break
 		}
 	}
 
-	return expr, nil
-}
-
+	_, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after list pattern.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ListPattern{Bracket: bracket, Elements: elements, HasRest: hasRest, Rest: rest}, nil
+}
+
+// classPattern parses "Point{x, y: 0}" once the '{' has been consumed.
+func (p *Parser) classPattern(name token.Token) (ast.Pattern, error) {
+	fields, patterns, err := p.fieldPatterns(p.pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ClassPattern{Class: &ast.Variable{Name: name}, Fields: fields, Patterns: patterns}, nil
+}
+
+// objectPattern parses "{x, y: [a, b]}" once the '{' has been consumed.
+func (p *Parser) objectPattern(element func() (ast.Pattern, error)) (ast.Pattern, error) {
+	brace := p.previous()
+
+	fields, patterns, err := p.fieldPatterns(element)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ObjectPattern{Brace: brace, Fields: fields, Patterns: patterns}, nil
+}
+
+// fieldPatterns parses the "x, y: pattern" fields of a class or object
+// pattern up to the closing '}'. A field on its own binds its value to a
+// variable of the same name.
+func (p *Parser) fieldPatterns(element func() (ast.Pattern, error)) ([]token.Token, []ast.Pattern, error) {
+	fields := []token.Token{}
+	patterns := []ast.Pattern{}
+
+	for !p.check(token.RIGHT_BRACE) {
+		field, err := p.consume(token.IDENTIFIER, "Expect field name in pattern.")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var pattern ast.Pattern = &ast.BindingPattern{Name: field}
+		if p.match(token.COLON) {
+			pattern, err = element()
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		fields = append(fields, field)
+		patterns = append(patterns, pattern)
+
+		if !p.match(token.COMMA) {
+			break
+		}
+	}
+
+	_, err := p.consume(token.RIGHT_BRACE, "Expect '}' after pattern fields.")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fields, patterns, nil
+}
+
+// destructuringPattern parses the left-hand side of a destructuring
+// declaration, which unlike a match pattern can only bind names.
+func (p *Parser) destructuringPattern() (ast.Pattern, error) {
+	if p.match(token.LEFT_BRACKET) {
+		return p.listPattern(p.destructuringPattern)
+	}
+
+	if p.match(token.LEFT_BRACE) {
+		return p.objectPattern(p.destructuringPattern)
+	}
+
+	name, err := p.consume(token.IDENTIFIER, "Expect variable name or pattern.")
+	if err != nil {
+		return nil, err
+	}
+
+	if name.Lexeme == "_" {
+		return &ast.WildcardPattern{Token: name}, nil
+	}
+
+	return &ast.BindingPattern{Name: name}, nil
+}
+
+func (p *Parser) blockStatement(kind string) (*ast.Block, error) {
+	_, err := p.consume(token.LEFT_BRACE, "Expect '{' after '"+kind+"'.")
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Block{Statements: statements}, nil
+}
+
+// varDeclaration parses the rest of a "var", "let" or "const" declaration.
+// "let" is the same as "var", while a "const" binding must be initialised
+// and can't be reassigned.
+func (p *Parser) varDeclaration() (ast.Stmt, error) {
+	keyword := p.previous()
+	constant := keyword.Type == token.CONST
+
+	if p.check(token.LEFT_BRACKET) || p.check(token.LEFT_BRACE) {
+		return p.destructuringDeclaration(keyword, constant)
+	}
+
+	name, err := p.consume(token.IDENTIFIER, "Expect variable name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var initialiser ast.Expr
+	if p.match(token.EQUAL) {
+		initialiser, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	} else if constant {
+		return nil, p.error(p.peek(), "Expect '=' after constant name.")
+	}
+
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after variable declaration.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Var{Name: name, Initialiser: initialiser, Constant: constant}, nil
+}
+
+// destructuringDeclaration parses "var [a, b] = list;" or "var {x, y} = point;"
+// after the keyword. The initialiser is required.
+func (p *Parser) destructuringDeclaration(keyword token.Token, constant bool) (ast.Stmt, error) {
+	pattern, err := p.destructuringPattern()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.EQUAL, "Expect '=' after destructuring pattern.")
+	if err != nil {
+		return nil, err
+	}
+
+	initialiser, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after variable declaration.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Destructure{Keyword: keyword, Pattern: pattern, Initialiser: initialiser, Constant: constant}, nil
+}
+
+func (p *Parser) whileStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+
+	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after 'while'.")
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.RIGHT_PAREN, "Expect ')' after condition.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.loopBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.While{Keyword: keyword, Condition: condition, Body: body}, nil
+}
+
+func (p *Parser) loopBody() (ast.Stmt, error) {
+	p.loopDepth++
+	defer func() {
+		p.loopDepth--
+	}()
+
+	return p.statement()
+}
+
+func (p *Parser) breakStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	if p.loopDepth == 0 {
+		_ = p.error(keyword, "Can't use 'break' outside of a loop.")
+	}
+
+	_, err := p.consume(token.SEMICOLON, "Expect ';' after 'break'.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Break{Keyword: keyword}, nil
+}
+
+func (p *Parser) continueStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	if p.loopDepth == 0 {
+		_ = p.error(keyword, "Can't use 'continue' outside of a loop.")
+	}
+
+	_, err := p.consume(token.SEMICOLON, "Expect ';' after 'continue'.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Continue{Keyword: keyword}, nil
+}
+
+func (p *Parser) expressionStatement() (ast.Stmt, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after expression.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Expression{Expression: expr}, nil
+}
+
+func (p *Parser) function(kind string) (*ast.Function, error) {
+	name, err := p.consume(token.IDENTIFIER, "Expect "+kind+" name.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	if err != nil {
+		return nil, err
+	}
+
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.functionBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Function{Name: name, Params: parameters, Body: body}, nil
+}
+
+// parameters parses a parameter list up to and including its closing ')'.
+func (p *Parser) parameters() ([]token.Token, error) {
+	parameters := []token.Token{}
+	if !p.check(token.RIGHT_PAREN) {
+		for {
+			if len(parameters) >= 255 {
+				_ = p.error(p.peek(), "Can't have more than 255 parameters.")
+			}
+
+			param, err := p.consume(token.IDENTIFIER, "Expect parameter name.")
+			if err != nil {
+				return nil, err
+			}
+
+			parameters = append(parameters, param)
+
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(token.RIGHT_PAREN, "Expect ')' after parameters.")
+	if err != nil {
+		return nil, err
+	}
+
+	return parameters, nil
+}
+
+// functionBody parses the statements of a body whose '{' has already been
+// consumed.
+func (p *Parser) functionBody() ([]ast.Stmt, error) {
+	// a loop surrounding the declaration doesn't extend into the body
+	enclosingLoopDepth := p.loopDepth
+	p.loopDepth = 0
+	defer func() {
+		p.loopDepth = enclosingLoopDepth
+	}()
+
+	return p.block()
+}
+
+func (p *Parser) block() ([]ast.Stmt, error) {
+	statements := []ast.Stmt{}
+
+	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		res, err := p.declaration()
+		if err != nil {
+			return nil, err
+		}
+
+		statements = append(statements, res)
+	}
+
+	_, err := p.consume(token.RIGHT_BRACE, "Expect '}' after block.")
+	if err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
+
+func (p *Parser) assignment() (ast.Expr, error) {
+	if p.check(token.LEFT_BRACKET) && p.isDestructuringAssignment() {
+		p.advance()
+		return p.destructuringAssignment()
+	}
+
+	expr, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.EQUAL) {
+		equals := p.previous()
+		val, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+
+		varExpr, ok := expr.(*ast.Variable)
+		if ok {
+			name := varExpr.Name
+
+			return &ast.Assign{
+				Name:  name,
+				Value: val,
+			}, nil
+		}
+
+		getExpr, ok := expr.(*ast.Get)
+		if ok {
+			return &ast.Set{
+				Object: getExpr.Object,
+				Name:   getExpr.Name,
+				Value:  val,
+			}, nil
+		}
+
+		indexExpr, ok := expr.(*ast.Index)
+		if ok {
+			return &ast.IndexSet{
+				Object:  indexExpr.Object,
+				Bracket: indexExpr.Bracket,
+				Index:   indexExpr.Index,
+				Value:   val,
+			}, nil
+		}
+
+		err = p.error(equals, "Invalid assignment target.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.match(token.PLUS_EQUAL, token.MINUS_EQUAL, token.STAR_EQUAL, token.SLASH_EQUAL, token.PERCENT_EQUAL) {
+		operator := p.previous()
+		val, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.isAssignable(expr) {
+			return nil, p.error(operator, "Invalid assignment target.")
+		}
+
+		return &ast.CompoundAssign{Target: expr, Operator: operator, Value: val}, nil
+	}
+
+	return expr, nil
+}
+
+// destructuringAssignment parses "[a, b, ...rest] = value" once the '[' has
+// been consumed. Each target can be anything a plain assignment could
+// assign to.
+func (p *Parser) destructuringAssignment() (ast.Expr, error) {
+	bracket := p.previous()
+	targets := []ast.Expr{}
+	var rest ast.Expr
+
+	for !p.check(token.RIGHT_BRACKET) {
+		isRest := p.match(token.ELLIPSIS)
+
+		target, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.isAssignable(target) {
+			return nil, p.error(p.previous(), "Invalid assignment target.")
+		}
+
+		if isRest {
+			rest = target
+			break
+		}
+
+		targets = append(targets, target)
+
+		if !p.match(token.COMMA) {
+			break
+		}
+	}
+
+	_, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after assignment targets.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.EQUAL, "Expect '=' after assignment targets.")
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.DestructureAssign{Bracket: bracket, Targets: targets, Rest: rest, Value: value}, nil
+}
+
+// isDestructuringAssignment looks ahead from a '[' for its closing ']'
+// followed by '=', without consuming anything, to tell "[a, b] = pair" apart
+// from a list literal.
+func (p *Parser) isDestructuringAssignment() bool {
+	depth := 0
+	for idx := p.Current; idx < len(p.Tokens); idx++ {
+		switch p.Tokens[idx].Type {
+		case token.LEFT_BRACKET, token.LEFT_PAREN, token.LEFT_BRACE:
+			depth++
+		case token.RIGHT_BRACKET, token.RIGHT_PAREN, token.RIGHT_BRACE:
+			depth--
+			if depth == 0 {
+				return p.Tokens[idx+1].Type == token.EQUAL
+			}
+		case token.SEMICOLON, token.EOF:
+			return false
+		}
+	}
+
+	return false
+}
+
+// isAssignable reports whether expr names a location that compound
+// assignment and increment operators can read and write.
+func (p *Parser) isAssignable(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.Variable, *ast.Get, *ast.Index:
+		return true
+	}
+
+	return false
+}
+
+func (p *Parser) ternary() (ast.Expr, error) {
+	condition, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.QUESTION) {
+		thenBranch, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.consume(token.COLON, "Expect ':' after then branch of conditional expression.")
+		if err != nil {
+			return nil, err
+		}
+
+		elseBranch, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.Ternary{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}, nil
+	}
+
+	return condition, nil
+}
+
+func (p *Parser) or() (ast.Expr, error) {
+	expr, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.OR) {
+		operator := p.previous()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Logical{Left: expr, Operator: operator, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) and() (ast.Expr, error) {
+	expr, err := p.equality()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.AND) {
+		operator := p.previous()
+		right, err := p.equality()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Logical{Left: expr, Operator: operator, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) equality() (ast.Expr, error) {
+	expr, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.BANG_EQUAL, token.EQUAL_EQUAL) {
+		operator := p.previous()
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) comparison() (ast.Expr, error) {
+	expr, err := p.bitwiseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL) {
+		operator := p.previous()
+		right, err := p.bitwiseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) bitwiseOr() (ast.Expr, error) {
+	expr, err := p.bitwiseXor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.PIPE) {
+		operator := p.previous()
+		right, err := p.bitwiseXor()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) bitwiseXor() (ast.Expr, error) {
+	expr, err := p.bitwiseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.CARET) {
+		operator := p.previous()
+		right, err := p.bitwiseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) bitwiseAnd() (ast.Expr, error) {
+	expr, err := p.shift()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.AMPERSAND) {
+		operator := p.previous()
+		right, err := p.shift()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) shift() (ast.Expr, error) {
+	expr, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.LESS_LESS, token.GREATER_GREATER) {
+		operator := p.previous()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
 func (p *Parser) term() (ast.Expr, error) {
 	expr, err := p.factor()
 	if err != nil {
@@ -433,7 +1400,7 @@ func (p *Parser) factor() (ast.Expr, error) {
 		return nil, err
 	}
 
-	for p.match(token.SLASH, token.STAR) {
+	for p.match(token.SLASH, token.STAR, token.PERCENT) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -451,7 +1418,21 @@ func (p *Parser) factor() (ast.Expr, error) {
 }
 
 func (p *Parser) unary() (ast.Expr, error) {
-	if p.match(token.BANG, token.MINUS) {
+	if p.match(token.PLUS_PLUS, token.MINUS_MINUS) {
+		operator := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.isAssignable(target) {
+			return nil, p.error(operator, "Invalid increment target.")
+		}
+
+		return &ast.Increment{Target: target, Operator: operator, Prefix: true}, nil
+	}
+
+	if p.match(token.BANG, token.MINUS, token.TILDE) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -464,7 +1445,118 @@ func (p *Parser) unary() (ast.Expr, error) {
 		}, nil
 	}
 
-	return p.primary()
+	return p.exponent()
+}
+
+// exponent binds tighter than unary on its left, so -2 ** 2 is -(2 ** 2), but
+// takes a unary on its right and recurses to be right-associative.
+func (p *Parser) exponent() (ast.Expr, error) {
+	expr, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.STAR_STAR) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ast.Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) postfix() (ast.Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.PLUS_PLUS, token.MINUS_MINUS) {
+		operator := p.previous()
+		if !p.isAssignable(expr) {
+			return nil, p.error(operator, "Invalid increment target.")
+		}
+
+		return &ast.Increment{Target: expr, Operator: operator, Prefix: false}, nil
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) call() (ast.Expr, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.match(token.LEFT_PAREN) {
+			expr, err = p.finishCall(expr)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.match(token.DOT) {
+			name, err := p.consume(token.IDENTIFIER, "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+
+			expr = &ast.Get{Object: expr, Name: name}
+		} else if p.match(token.LEFT_BRACKET) {
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			bracket, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+
+			expr = &ast.Index{Object: expr, Bracket: bracket, Index: index}
+		} else {
+			break
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) finishCall(callee ast.Expr) (ast.Expr, error) {
+	arguments := []ast.Expr{}
+	if !p.check(token.RIGHT_PAREN) {
+		for {
+			if len(arguments) >= 255 {
+				_ = p.error(p.peek(), "Can't have more than 255 arguments.")
+			}
+
+			argument, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			arguments = append(arguments, argument)
+
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	paren, err := p.consume(token.RIGHT_PAREN, "Expect ')' after arguments.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Call{Callee: callee, Paren: paren, Arguments: arguments}, nil
 }
 
 func (p *Parser) primary() (ast.Expr, error) {
@@ -484,10 +1576,57 @@ func (p *Parser) primary() (ast.Expr, error) {
 		return &ast.Literal{Value: p.previous().Literal}, nil
 	}
 
+	if p.match(token.INTERPOLATION) {
+		return p.interpolation()
+	}
+
+	if p.match(token.SUPER) {
+		keyword := p.previous()
+		_, err := p.consume(token.DOT, "Expect '.' after 'super'.")
+		if err != nil {
+			return nil, err
+		}
+
+		method, err := p.consume(token.IDENTIFIER, "Expect superclass method name.")
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.Super{Keyword: keyword, Method: method}, nil
+	}
+
+	if p.match(token.THIS) {
+		return &ast.This{Keyword: p.previous()}, nil
+	}
+
+	if p.isMatch() {
+		p.advance()
+		return p.matchExpression()
+	}
+
 	if p.match(token.IDENTIFIER) {
 		return &ast.Variable{Name: p.previous()}, nil
 	}
 
+	if p.match(token.LEFT_BRACKET) {
+		return p.listLiteral()
+	}
+
+	// a brace at the start of a statement is always a block, so reaching
+	// one here means the braces hold a map literal
+	if p.match(token.LEFT_BRACE) {
+		return p.mapLiteral()
+	}
+
+	if p.match(token.FUN) {
+		return p.lambda()
+	}
+
+	if !p.inGuard && p.check(token.LEFT_PAREN) && p.isArrowLambda() {
+		p.advance()
+		return p.arrowLambda()
+	}
+
 	if p.match(token.LEFT_PAREN) {
 		expr, err := p.expression()
 		if err != nil {
@@ -501,7 +1640,209 @@ func (p *Parser) primary() (ast.Expr, error) {
 		return &ast.Grouping{Expression: expr}, nil
 	}
 
-	return nil, p.error(p.peek(), " Expect expression.")
+	return nil, p.error(p.peek(), "Expect expression.")
+}
+
+// interpolation parses the tokens the scanner produces for a string such as
+// "a ${b} c ${d} e": an INTERPOLATION for each part before an embedded
+// expression, followed by the expression itself, and a final STRING part.
+func (p *Parser) interpolation() (ast.Expr, error) {
+	parts := []ast.Expr{}
+
+	for {
+		parts = append(parts, &ast.Literal{Value: p.previous().Literal})
+
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, expr)
+
+		if !p.match(token.INTERPOLATION) {
+			break
+		}
+	}
+
+	_, err := p.consume(token.STRING, "Expect end of string after interpolated expression.")
+	if err != nil {
+		return nil, err
+	}
+
+	parts = append(parts, &ast.Literal{Value: p.previous().Literal})
+
+	return &ast.Interpolation{Parts: parts}, nil
+}
+
+func (p *Parser) lambda() (ast.Expr, error) {
+	keyword := p.previous()
+
+	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after 'fun'.")
+	if err != nil {
+		return nil, err
+	}
+
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before function body.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.functionBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.Lambda{Function: &ast.Function{Name: keyword, Params: parameters, Body: body}}, nil
+}
+
+// arrowLambda parses "(a, b) => a + b", or "(a, b) => { ... }" for a body
+// with statements, once the opening '(' has been consumed.
+func (p *Parser) arrowLambda() (ast.Expr, error) {
+	paren := p.previous()
+
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	arrow, err := p.consume(token.ARROW, "Expect '=>' after lambda parameters.")
+	if err != nil {
+		return nil, err
+	}
+
+	var body []ast.Stmt
+	if p.match(token.LEFT_BRACE) {
+		body, err = p.functionBody()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		body = []ast.Stmt{&ast.Return{Keyword: arrow, Value: value}}
+	}
+
+	return &ast.Lambda{Function: &ast.Function{Name: paren, Params: parameters, Body: body}}, nil
+}
+
+// isMatch looks ahead from the word "match" for "(subject) {", without
+// consuming anything. "match" isn't reserved, and a call to a function
+// named match can't be followed by a '{', so that tells the two apart.
+func (p *Parser) isMatch() bool {
+	if !p.checkWord("match") || !p.checkNext(token.LEFT_PAREN) {
+		return false
+	}
+
+	depth := 0
+	for idx := p.Current + 1; p.Tokens[idx].Type != token.EOF; idx++ {
+		switch p.Tokens[idx].Type {
+		case token.LEFT_PAREN:
+			depth++
+		case token.RIGHT_PAREN:
+			depth--
+			if depth == 0 {
+				return p.Tokens[idx+1].Type == token.LEFT_BRACE
+			}
+		}
+	}
+
+	return false
+}
+
+// isArrowLambda looks ahead from a '(' for a parameter list followed by "=>",
+// without consuming anything, to tell a lambda apart from a grouping.
+func (p *Parser) isArrowLambda() bool {
+	idx := p.Current + 1
+	if p.Tokens[idx].Type != token.RIGHT_PAREN {
+		for {
+			if p.Tokens[idx].Type != token.IDENTIFIER {
+				return false
+			}
+			idx++
+
+			if p.Tokens[idx].Type != token.COMMA {
+				break
+			}
+			idx++
+		}
+
+		if p.Tokens[idx].Type != token.RIGHT_PAREN {
+			return false
+		}
+	}
+
+	return p.Tokens[idx+1].Type == token.ARROW
+}
+
+func (p *Parser) listLiteral() (ast.Expr, error) {
+	elements := []ast.Expr{}
+	if !p.check(token.RIGHT_BRACKET) {
+		for {
+			element, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			elements = append(elements, element)
+
+			// allow a trailing comma before the closing bracket
+			if !p.match(token.COMMA) || p.check(token.RIGHT_BRACKET) {
+				break
+			}
+		}
+	}
+
+	bracket, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ListLiteral{Bracket: bracket, Elements: elements}, nil
+}
+
+func (p *Parser) mapLiteral() (ast.Expr, error) {
+	keys := []ast.Expr{}
+	values := []ast.Expr{}
+	if !p.check(token.RIGHT_BRACE) {
+		for {
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = p.consume(token.COLON, "Expect ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+			values = append(values, value)
+
+			if !p.match(token.COMMA) || p.check(token.RIGHT_BRACE) {
+				break
+			}
+		}
+	}
+
+	brace, err := p.consume(token.RIGHT_BRACE, "Expect '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.MapLiteral{Brace: brace, Keys: keys, Values: values}, nil
 }
 
 func (p *Parser) match(types ...token.TTokentype) bool {
@@ -532,6 +1873,29 @@ func (p *Parser) check(ttype token.TTokentype) bool {
 	return p.peek().Type == ttype
 }
 
+func (p *Parser) checkNext(ttype token.TTokentype) bool {
+	if p.isAtEnd() || p.Tokens[p.Current+1].Type == token.EOF {
+		return false
+	}
+
+	return p.Tokens[p.Current+1].Type == ttype
+}
+
+// checkWord reports whether the next token is the identifier word. Words
+// such as "from" only mean something in one place, so they aren't reserved
+// and can still be used as names everywhere else.
+func (p *Parser) checkWord(word string) bool {
+	return p.check(token.IDENTIFIER) && p.peek().Lexeme == word
+}
+
+func (p *Parser) consumeWord(word string, message string) (token.Token, error) {
+	if p.checkWord(word) {
+		return p.advance(), nil
+	}
+
+	return token.Token{}, p.error(p.peek(), message)
+}
+
 func (p *Parser) advance() token.Token {
 	if !p.isAtEnd() {
 		p.Current++
@@ -553,11 +1917,7 @@ func (p *Parser) previous() token.Token {
 }
 
 func (p *Parser) error(ttoken token.Token, message string) error {
-	if ttoken.Type == token.EOF {
-		p.Lox.Error(ttoken.Line, " at end"+message)
-	} else {
-		p.Lox.Error(ttoken.Line, fmt.Sprintf(" at '%s'%s", ttoken.Lexeme, message))
-	}
+	p.Lox.TokenError(ttoken, message)
 
 	return NewParserError(ttoken.Line, message)
 }
@@ -571,17 +1931,14 @@ func (p *Parser) synchronise() {
 		}
 
 		switch p.peek().Type {
-		case token.CLASS:
-		case token.FUN:
-		case token.VAR:
-		case token.FOR:
-		case token.IF:
-		case token.WHILE:
-		case token.PRINT:
-		case token.RETURN:
+		case token.CLASS, token.FUN, token.IMPORT, token.VAR, token.LET, token.CONST, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN:
 			return
 		}
-	}
 
-	p.advance()
+		if p.isMatch() {
+			return
+		}
+
+		p.advance()
+	}
 }
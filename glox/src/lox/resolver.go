@@ -0,0 +1,686 @@
+package lox
+
+import (
+	"github.com/dmcg310/glox/src/ast"
+	"github.com/dmcg310/glox/src/token"
+)
+
+type FunctionType int
+
+const (
+	NONE FunctionType = iota
+	FUNCTION
+	INITIALISER
+	METHOD
+)
+
+type ClassType int
+
+const (
+	NONE_CLASS ClassType = iota
+	CLASS
+	SUBCLASS
+)
+
+type Resolver struct {
+	Interpreter     *Interpreter
+	Lox             *Lox
+	scopes          []map[string]bool
+	constants       []map[string]bool
+	globalConstants map[string]bool
+	currentFunction FunctionType
+	currentClass    ClassType
+}
+
+func NewResolver(interpreter *Interpreter, lox *Lox) Resolver {
+	return Resolver{
+		Interpreter:     interpreter,
+		Lox:             lox,
+		scopes:          []map[string]bool{},
+		constants:       []map[string]bool{},
+		globalConstants: make(map[string]bool),
+		currentFunction: NONE,
+		currentClass:    NONE_CLASS,
+	}
+}
+
+func (r *Resolver) resolve(statements []ast.Stmt) {
+	for _, statement := range statements {
+		r.resolveStmt(statement)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt ast.Stmt) {
+	if stmt == nil {
+		return
+	}
+
+	_, _ = stmt.Accept(r)
+}
+
+func (r *Resolver) resolveExpr(expr ast.Expr) {
+	if expr == nil {
+		return
+	}
+
+	_, _ = expr.Accept(r)
+}
+
+func (r *Resolver) resolveFunction(function *ast.Function, functionType FunctionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = functionType
+
+	r.beginScope()
+	for _, param := range function.Params {
+		r.declare(param)
+		r.define(param)
+	}
+
+	r.resolve(function.Body)
+	r.endScope()
+
+	r.currentFunction = enclosingFunction
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, map[string]bool{})
+	r.constants = append(r.constants, map[string]bool{})
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.constants = r.constants[:len(r.constants)-1]
+}
+
+func (r *Resolver) declare(name token.Token) {
+	if len(r.scopes) == 0 {
+		// globals can be redeclared, and a plain redeclaration drops constness
+		delete(r.globalConstants, name.Lexeme)
+		return
+	}
+
+	scope := r.scopes[len(r.scopes)-1]
+	if _, ok := scope[name.Lexeme]; ok {
+		r.Lox.TokenError(name, "Already a variable with this name in this scope.")
+	}
+
+	scope[name.Lexeme] = false
+}
+
+func (r *Resolver) define(name token.Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+
+	r.scopes[len(r.scopes)-1][name.Lexeme] = true
+}
+
+// declareConstant marks a name declared in the current scope as constant.
+func (r *Resolver) declareConstant(name token.Token) {
+	if len(r.scopes) == 0 {
+		r.globalConstants[name.Lexeme] = true
+		return
+	}
+
+	r.constants[len(r.constants)-1][name.Lexeme] = true
+}
+
+// checkAssignable reports an assignment to a constant found in the scope the
+// name resolves to. Globals are only known about if declared earlier in the
+// same file, so the interpreter checks again at runtime.
+func (r *Resolver) checkAssignable(name token.Token) {
+	for idx := len(r.scopes) - 1; idx >= 0; idx-- {
+		if _, ok := r.scopes[idx][name.Lexeme]; ok {
+			if r.constants[idx][name.Lexeme] {
+				r.Lox.TokenError(name, "Can't assign to constant '"+name.Lexeme+"'.")
+			}
+
+			return
+		}
+	}
+
+	if r.globalConstants[name.Lexeme] {
+		r.Lox.TokenError(name, "Can't assign to constant '"+name.Lexeme+"'.")
+	}
+}
+
+// checkTarget applies checkAssignable to a read-modify-write target when it
+// names a variable.
+func (r *Resolver) checkTarget(target ast.Expr) {
+	if variable, ok := target.(*ast.Variable); ok {
+		r.checkAssignable(variable.Name)
+	}
+}
+
+func (r *Resolver) resolveLocal(expr ast.Expr, name token.Token) {
+	for idx := len(r.scopes) - 1; idx >= 0; idx-- {
+		if _, ok := r.scopes[idx][name.Lexeme]; ok {
+			r.Interpreter.resolve(expr, len(r.scopes)-1-idx)
+			return
+		}
+	}
+}
+
+func (r *Resolver) VisitBlock(stmt *ast.Block) (interface{}, error) {
+	r.beginScope()
+	r.resolve(stmt.Statements)
+	r.endScope()
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitBreak(stmt *ast.Break) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitContinue(stmt *ast.Continue) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitClass(stmt *ast.Class) (interface{}, error) {
+	enclosingClass := r.currentClass
+	r.currentClass = CLASS
+
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+
+	if stmt.Superclass != nil {
+		if stmt.Name.Lexeme == stmt.Superclass.Name.Lexeme {
+			r.Lox.TokenError(stmt.Superclass.Name, "A class can't inherit from itself.")
+		}
+
+		r.currentClass = SUBCLASS
+		r.resolveExpr(stmt.Superclass)
+
+		r.beginScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+	}
+
+	r.beginScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+
+	for _, method := range stmt.Methods {
+		declaration := METHOD
+		if method.Name.Lexeme == "init" {
+			declaration = INITIALISER
+		}
+
+		r.resolveFunction(method, declaration)
+	}
+
+	r.endScope()
+
+	if stmt.Superclass != nil {
+		r.endScope()
+	}
+
+	r.currentClass = enclosingClass
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitDestructure(stmt *ast.Destructure) (interface{}, error) {
+	names := patternBindings(stmt.Pattern)
+	for _, name := range names {
+		r.declare(name)
+		if stmt.Constant {
+			r.declareConstant(name)
+		}
+	}
+
+	r.resolveExpr(stmt.Initialiser)
+
+	for _, name := range names {
+		r.define(name)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitExpression(stmt *ast.Expression) (interface{}, error) {
+	r.resolveExpr(stmt.Expression)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitFunction(stmt *ast.Function) (interface{}, error) {
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+
+	r.resolveFunction(stmt, FUNCTION)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIf(stmt *ast.If) (interface{}, error) {
+	r.resolveExpr(stmt.Condition)
+	r.resolveStmt(stmt.ThenBranch)
+	r.resolveStmt(stmt.ElseBranch)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitImport(stmt *ast.Import) (interface{}, error) {
+	if len(r.scopes) > 0 {
+		r.Lox.TokenError(stmt.Keyword, "Can only import at the top level of a file.")
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitMatchStmt(stmt *ast.MatchStmt) (interface{}, error) {
+	r.resolveExpr(stmt.Subject)
+	r.resolveArms(stmt.Arms)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitPrint(stmt *ast.Print) (interface{}, error) {
+	r.resolveExpr(stmt.Expression)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitReturn(stmt *ast.Return) (interface{}, error) {
+	if r.currentFunction == NONE {
+		r.Lox.TokenError(stmt.Keyword, "Can't return from top-level code.")
+	}
+
+	if stmt.Value != nil {
+		if r.currentFunction == INITIALISER {
+			r.Lox.TokenError(stmt.Keyword, "Can't return a value from an initialiser.")
+		}
+
+		r.resolveExpr(stmt.Value)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitThrow(stmt *ast.Throw) (interface{}, error) {
+	r.resolveExpr(stmt.Value)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitTry(stmt *ast.Try) (interface{}, error) {
+	r.resolveStmt(stmt.Body)
+
+	if stmt.CatchBody != nil {
+		r.beginScope()
+		r.declare(stmt.CatchName)
+		r.define(stmt.CatchName)
+		r.resolve(stmt.CatchBody.Statements)
+		r.endScope()
+	}
+
+	if stmt.FinallyBody != nil {
+		r.resolveStmt(stmt.FinallyBody)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitVar(stmt *ast.Var) (interface{}, error) {
+	r.declare(stmt.Name)
+	if stmt.Constant {
+		r.declareConstant(stmt.Name)
+	}
+
+	r.resolveExpr(stmt.Initialiser)
+	r.define(stmt.Name)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitWhile(stmt *ast.While) (interface{}, error) {
+	r.resolveExpr(stmt.Condition)
+	r.resolveStmt(stmt.Body)
+	r.resolveExpr(stmt.Increment)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitAssign(expr *ast.Assign) (interface{}, error) {
+	r.resolveExpr(expr.Value)
+	r.resolveLocal(expr, expr.Name)
+	r.checkAssignable(expr.Name)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitBinary(expr *ast.Binary) (interface{}, error) {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitCall(expr *ast.Call) (interface{}, error) {
+	r.resolveExpr(expr.Callee)
+
+	for _, argument := range expr.Arguments {
+		r.resolveExpr(argument)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitCompoundAssign(expr *ast.CompoundAssign) (interface{}, error) {
+	r.resolveExpr(expr.Value)
+	r.resolveExpr(expr.Target)
+	r.checkTarget(expr.Target)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitDestructureAssign(expr *ast.DestructureAssign) (interface{}, error) {
+	r.resolveExpr(expr.Value)
+
+	for _, target := range expr.Targets {
+		r.resolveExpr(target)
+		r.checkTarget(target)
+	}
+
+	if expr.Rest != nil {
+		r.resolveExpr(expr.Rest)
+		r.checkTarget(expr.Rest)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIncrement(expr *ast.Increment) (interface{}, error) {
+	r.resolveExpr(expr.Target)
+	r.checkTarget(expr.Target)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitGet(expr *ast.Get) (interface{}, error) {
+	r.resolveExpr(expr.Object)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitGrouping(expr *ast.Grouping) (interface{}, error) {
+	r.resolveExpr(expr.Expression)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndex(expr *ast.Index) (interface{}, error) {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Index)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexSet(expr *ast.IndexSet) (interface{}, error) {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Index)
+	r.resolveExpr(expr.Value)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitInterpolation(expr *ast.Interpolation) (interface{}, error) {
+	for _, part := range expr.Parts {
+		r.resolveExpr(part)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitLambda(expr *ast.Lambda) (interface{}, error) {
+	r.resolveFunction(expr.Function, FUNCTION)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitListLiteral(expr *ast.ListLiteral) (interface{}, error) {
+	for _, element := range expr.Elements {
+		r.resolveExpr(element)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteral(expr *ast.Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogical(expr *ast.Logical) (interface{}, error) {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapLiteral(expr *ast.MapLiteral) (interface{}, error) {
+	for idx, key := range expr.Keys {
+		r.resolveExpr(key)
+		r.resolveExpr(expr.Values[idx])
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitMatch(expr *ast.Match) (interface{}, error) {
+	r.resolveExpr(expr.Subject)
+	r.resolveArms(expr.Arms)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitSet(expr *ast.Set) (interface{}, error) {
+	r.resolveExpr(expr.Value)
+	r.resolveExpr(expr.Object)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitSuper(expr *ast.Super) (interface{}, error) {
+	if r.currentClass == NONE_CLASS {
+		r.Lox.TokenError(expr.Keyword, "Can't use 'super' outside of a class.")
+		return nil, nil
+	} else if r.currentClass != SUBCLASS {
+		r.Lox.TokenError(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+		return nil, nil
+	}
+
+	r.resolveLocal(expr, expr.Keyword)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitTernary(expr *ast.Ternary) (interface{}, error) {
+	r.resolveExpr(expr.Condition)
+	r.resolveExpr(expr.ThenBranch)
+	r.resolveExpr(expr.ElseBranch)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitThis(expr *ast.This) (interface{}, error) {
+	if r.currentClass == NONE_CLASS {
+		r.Lox.TokenError(expr.Keyword, "Can't use 'this' outside of a class.")
+		return nil, nil
+	}
+
+	r.resolveLocal(expr, expr.Keyword)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnary(expr *ast.Unary) (interface{}, error) {
+	r.resolveExpr(expr.Right)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitVariable(expr *ast.Variable) (interface{}, error) {
+	if len(r.scopes) > 0 {
+		if defined, ok := r.scopes[len(r.scopes)-1][expr.Name.Lexeme]; ok && !defined {
+			r.Lox.TokenError(expr.Name, "Can't read local variable in its own initialiser.")
+		}
+	}
+
+	r.resolveLocal(expr, expr.Name)
+
+	return nil, nil
+}
+
+// resolveArms resolves each arm in its own scope, which holds the variables
+// its pattern binds. Arms that can never run, because an earlier unguarded
+// arm matches everything or already covers all their literals, are warned
+// about.
+func (r *Resolver) resolveArms(arms []*ast.MatchArm) {
+	exhaustive := false
+	literals := []interface{}{}
+
+	for _, arm := range arms {
+		if exhaustive || r.coveredBy(arm.Pattern, literals) {
+			r.Lox.TokenWarning(arm.Keyword, "Unreachable match arm.")
+		}
+
+		r.beginScope()
+		r.resolvePattern(arm.Pattern)
+		r.resolveExpr(arm.Guard)
+		r.resolveStmt(arm.Body)
+		r.endScope()
+
+		if arm.Guard != nil {
+			continue
+		}
+
+		if irrefutable(arm.Pattern) {
+			exhaustive = true
+		}
+
+		literals = append(literals, patternLiterals(arm.Pattern)...)
+	}
+}
+
+func (r *Resolver) resolvePattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		r.declare(pattern.Name)
+		r.define(pattern.Name)
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			if names := patternBindings(alternative); len(names) > 0 {
+				r.Lox.TokenError(names[0], "Can't bind variables in alternative patterns.")
+			}
+
+			r.resolvePattern(alternative)
+		}
+	case *ast.ListPattern:
+		for _, element := range pattern.Elements {
+			r.resolvePattern(element)
+		}
+
+		if pattern.Rest != nil {
+			r.resolvePattern(pattern.Rest)
+		}
+	case *ast.ClassPattern:
+		r.resolveExpr(pattern.Class)
+
+		for _, field := range pattern.Patterns {
+			r.resolvePattern(field)
+		}
+	case *ast.ObjectPattern:
+		for _, field := range pattern.Patterns {
+			r.resolvePattern(field)
+		}
+	}
+}
+
+// coveredBy reports whether every value pattern could match is one of the
+// literals matched by earlier arms.
+func (r *Resolver) coveredBy(pattern ast.Pattern, literals []interface{}) bool {
+	switch pattern := pattern.(type) {
+	case *ast.LiteralPattern:
+		for _, literal := range literals {
+			if r.Interpreter.isEqual(literal, pattern.Value) {
+				return true
+			}
+		}
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			if !r.coveredBy(alternative, literals) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// irrefutable reports whether pattern matches every value.
+func irrefutable(pattern ast.Pattern) bool {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern, *ast.BindingPattern:
+		return true
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			if irrefutable(alternative) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// patternLiterals returns the values a literal pattern, or alternatives of
+// them, match.
+func patternLiterals(pattern ast.Pattern) []interface{} {
+	switch pattern := pattern.(type) {
+	case *ast.LiteralPattern:
+		return []interface{}{pattern.Value}
+	case *ast.AlternativePattern:
+		literals := []interface{}{}
+		for _, alternative := range pattern.Alternatives {
+			literals = append(literals, patternLiterals(alternative)...)
+		}
+
+		return literals
+	}
+
+	return nil
+}
+
+// patternBindings returns the names pattern binds, in order.
+func patternBindings(pattern ast.Pattern) []token.Token {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		return []token.Token{pattern.Name}
+	case *ast.AlternativePattern:
+		return patternsBindings(pattern.Alternatives)
+	case *ast.ListPattern:
+		names := patternsBindings(pattern.Elements)
+		if pattern.Rest != nil {
+			names = append(names, patternBindings(pattern.Rest)...)
+		}
+
+		return names
+	case *ast.ClassPattern:
+		return patternsBindings(pattern.Patterns)
+	case *ast.ObjectPattern:
+		return patternsBindings(pattern.Patterns)
+	}
+
+	return nil
+}
+
+func patternsBindings(patterns []ast.Pattern) []token.Token {
+	names := []token.Token{}
+	for _, pattern := range patterns {
+		names = append(names, patternBindings(pattern)...)
+	}
+
+	return names
+}
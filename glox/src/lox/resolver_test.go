@@ -0,0 +1,44 @@
+package lox
+
+import "testing"
+
+func TestResolver(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name: "closures bind statically",
+			source: `var a = "global";
+			{
+				fun show() { print a; }
+				var r = show();
+				var a = "block";
+				r = show();
+			}`,
+			want: "global\nglobal\n",
+		},
+		{
+			name:   "shadowing",
+			source: `var a = 1; { var a = 2; print a; } print a;`,
+			want:   "2\n1\n",
+		},
+		{
+			name:   "own initialiser",
+			source: "var a = 1; { var a = a; }",
+			err:    "Can't read local variable in its own initialiser.",
+		},
+		{
+			name:   "redeclaration",
+			source: "{ var a = 1; var a = 2; }",
+			err:    "Already a variable with this name in this scope.",
+		},
+		{
+			name:   "top level return",
+			source: "return 1;",
+			err:    "Can't return from top-level code.",
+		},
+		{
+			name:   "errors stop execution",
+			source: `print "before"; { var a = 1; var a = 2; }`,
+			err:    "Already a variable",
+		},
+	})
+}
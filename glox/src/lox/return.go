@@ -0,0 +1,12 @@
+package lox
+
+// Return unwinds the interpreter from a return statement back to the
+// enclosing LoxFunction call. It travels up the Visit* chain as an error so
+// every statement between the two is exited along the normal error path.
+type Return struct {
+	Value interface{}
+}
+
+func (r *Return) Error() string {
+	return "return outside of function call"
+}
@@ -0,0 +1,34 @@
+package lox
+
+import "testing"
+
+func TestStrings(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "escapes", source: `print "a\tb\\c\"d\"";`, want: "a\tb\\c\"d\"\n"},
+		{name: "newline escape", source: `print "line\nnext";`, want: "line\nnext\n"},
+		{name: "unicode escapes", source: `print "\u{48}\u{e9}\u{1F600}";`, want: "Hé😀\n"},
+		{name: "unicode source", source: `print "héllo wörld";`, want: "héllo wörld\n"},
+		{name: "length counts code points", source: `print len("héllo"); print len("😀");`, want: "5\n1\n"},
+		{name: "indexing by code point", source: `var s = "añb"; print s[1]; print s[2];`, want: "ñ\nb\n"},
+		{name: "slicing", source: `print slice("añbc", 1, 3); print slice([1, 2, 3], 0, 2);`, want: "ñb\n[1, 2]\n"},
+		{name: "index out of range", source: `print "ab"[2]; print "after";`, err: "Index out of range."},
+		{name: "strings are immutable", source: `var s = "ab"; s[0] = "c"; print "after";`, err: "Strings are immutable."},
+		{name: "invalid escape", source: `print "\q";`, err: `Invalid escape sequence '\q'.`},
+		{name: "missing brace", source: `print "\u41";`, err: `Expect '{' after '\u'.`},
+		{name: "invalid code point", source: `print "\u{110000}";`, err: `Unicode escape '\u{110000}' is not a valid code point.`},
+		{name: "invalid digits", source: `print "\u{zz}";`, err: `Invalid unicode escape sequence '\u{zz}'.`},
+	})
+}
+
+func TestInterpolation(t *testing.T) {
+	runTests(t, []scriptTest{
+		{name: "expression", source: `var name = "lox"; print "Hello ${name}!";`, want: "Hello lox!\n"},
+		{name: "several parts", source: `var a = 1; print "${a} + ${a} = ${a + a}";`, want: "1 + 1 = 2\n"},
+		{name: "non-string values", source: `print "${nil} ${true} ${[1, "a"]}";`, want: "nil true [1, \"a\"]\n"},
+		{name: "nested strings", source: `print "outer ${"inner ${1 + 2}"}";`, want: "outer inner 3\n"},
+		{name: "braces inside", source: `var m = {"k": "v"}; print "${m["k"]} ${{}}";`, want: "v {}\n"},
+		{name: "escaped dollar", source: `print "\${not}";`, want: "${not}\n"},
+		{name: "lone dollar", source: `print "$5";`, want: "$5\n"},
+		{name: "unterminated", source: `print "a ${1`, err: "Unterminated string interpolation."},
+	})
+}
@@ -0,0 +1,14 @@
+package lox
+
+import "github.com/dmcg310/glox/src/token"
+
+// Throw carries a value raised by a throw statement up to the nearest
+// enclosing try, or out of the interpreter if nothing catches it.
+type Throw struct {
+	Keyword token.Token
+	Value   interface{}
+}
+
+func (t *Throw) Error() string {
+	return "uncaught exception"
+}
@@ -0,0 +1,69 @@
+package lox
+
+import "testing"
+
+func TestExceptions(t *testing.T) {
+	runTests(t, []scriptTest{
+		{
+			name:   "catch a thrown value",
+			source: `try { throw "oops"; print "skipped"; } catch (e) { print e; }`,
+			want:   "oops\n",
+		},
+		{
+			name:   "catch a runtime error",
+			source: "try { var a = [1]; print a[5]; } catch (e) { print e.message; print e.line; }",
+			want:   "Index out of range.\n1\n",
+		},
+		{
+			name:   "throw unwinds calls",
+			source: `fun fail() { throw [1, 2]; } try { fail(); } catch (e) { print e[1]; }`,
+			want:   "2\n",
+		},
+		{
+			name:   "finally after success",
+			source: `try { print "body"; } finally { print "finally"; }`,
+			want:   "body\nfinally\n",
+		},
+		{
+			name:   "finally after catch",
+			source: `try { throw 1; } catch (e) { print "caught"; } finally { print "finally"; }`,
+			want:   "caught\nfinally\n",
+		},
+		{
+			name:   "finally runs on return",
+			source: `fun f() { try { return 1; } finally { print "finally"; } } print f();`,
+			want:   "finally\n1\n",
+		},
+		{
+			name:   "finally runs on break",
+			source: `while (true) { try { break; } finally { print "finally"; } } print "after";`,
+			want:   "finally\nafter\n",
+		},
+		{
+			name:   "rethrow from catch",
+			source: `try { try { throw "inner"; } catch (e) { throw "again"; } } catch (e) { print e; }`,
+			want:   "again\n",
+		},
+		{
+			name:   "return isn't caught",
+			source: `fun f() { try { return "returned"; } catch (e) { return "caught"; } } print f();`,
+			want:   "returned\n",
+		},
+		{
+			name:   "uncaught exception",
+			source: `print "before"; throw "oops"; print "after";`,
+			want:   "before\n",
+			err:    "Uncaught exception: oops\n[line 1]",
+		},
+		{
+			name:   "uncaught runtime error",
+			source: "var a = [];\nprint a[0];",
+			err:    "Index out of range.\n[line 2]",
+		},
+		{
+			name:   "try without catch or finally",
+			source: "try {}",
+			err:    "Expect 'catch' or 'finally' after try block.",
+		},
+	})
+}
@@ -1,16 +1,36 @@
 package report
 
-import "log"
+import (
+	"fmt"
+	"io"
+	"os"
+)
 
 type Reporter interface {
 	Error(line int, message string)
+	Warning(line int, message string)
 }
 
+// LoxReporter writes errors and warnings to Writer, or to standard error
+// if it isn't set.
 type LoxReporter struct {
 	HadError bool
+	Writer   io.Writer
 }
 
 func (r *LoxReporter) Error(line int, message string) {
-	log.Printf("[line %d] Error: %s\n", line, message)
+	fmt.Fprintf(r.writer(), "[line %d] Error: %s\n", line, message)
 	r.HadError = true
 }
+
+func (r *LoxReporter) Warning(line int, message string) {
+	fmt.Fprintf(r.writer(), "[line %d] Warning: %s\n", line, message)
+}
+
+func (r *LoxReporter) writer() io.Writer {
+	if r.Writer == nil {
+		return os.Stderr
+	}
+
+	return r.Writer
+}
@@ -1,24 +1,32 @@
 package scanner
 
 import (
+	"github.com/dmcg310/glox/src/decimal"
 	"github.com/dmcg310/glox/src/report"
 	"github.com/dmcg310/glox/src/token"
+	"math/big"
 	"strconv"
+	"strings"
+	"unicode"
 )
 
 type _Scanner struct {
-	source   string
+	source   []rune
 	tokens   []token.Token
 	start    int
 	current  int
 	line     int
 	reporter report.Reporter
 	keywords map[string]token.TTokentype
+
+	// open brace counts for each "${" the scanner is currently inside,
+	// innermost last
+	interpolations []int
 }
 
 func NewScanner(source string, reporter report.Reporter) _Scanner {
 	s := _Scanner{
-		source:   source,
+		source:   []rune(source),
 		reporter: reporter,
 	}
 	s.InitKeywords()
@@ -35,6 +43,10 @@ func (s *_Scanner) ScanTokens() []token.Token {
 		s.scanToken()
 	}
 
+	if len(s.interpolations) > 0 {
+		s.reporter.Error(s.line, "Unterminated string interpolation.")
+	}
+
 	s.tokens = append(s.tokens, token.NewToken(token.EOF, "", nil, s.line))
 	return s.tokens
 }
@@ -49,21 +61,86 @@ func (s *_Scanner) scanToken() {
 	case ')':
 		s.addToken(token.RIGHT_PAREN, nil)
 	case '{':
+		if len(s.interpolations) > 0 {
+			s.interpolations[len(s.interpolations)-1]++
+		}
 		s.addToken(token.LEFT_BRACE, nil)
 	case '}':
+		if len(s.interpolations) > 0 {
+			depth := len(s.interpolations) - 1
+			if s.interpolations[depth] == 0 {
+				// this brace closes the embedded expression, so carry on
+				// with the rest of the string it was embedded in
+				s.interpolations = s.interpolations[:depth]
+				s.string()
+				break
+			}
+
+			s.interpolations[depth]--
+		}
 		s.addToken(token.RIGHT_BRACE, nil)
+	case '[':
+		s.addToken(token.LEFT_BRACKET, nil)
+	case ']':
+		s.addToken(token.RIGHT_BRACKET, nil)
+	case ':':
+		s.addToken(token.COLON, nil)
 	case ',':
 		s.addToken(token.COMMA, nil)
 	case '.':
-		s.addToken(token.DOT, nil)
+		if s.peek() == '.' && s.peekNext() == '.' {
+			s.advance()
+			s.advance()
+			s.addToken(token.ELLIPSIS, nil)
+		} else {
+			s.addToken(token.DOT, nil)
+		}
 	case '-':
-		s.addToken(token.MINUS, nil)
+		if s.match('=') {
+			_token = token.MINUS_EQUAL
+		} else if s.match('-') {
+			_token = token.MINUS_MINUS
+		} else {
+			_token = token.MINUS
+		}
+		s.addToken(_token, nil)
 	case '+':
-		s.addToken(token.PLUS, nil)
+		if s.match('=') {
+			_token = token.PLUS_EQUAL
+		} else if s.match('+') {
+			_token = token.PLUS_PLUS
+		} else {
+			_token = token.PLUS
+		}
+		s.addToken(_token, nil)
 	case ';':
 		s.addToken(token.SEMICOLON, nil)
 	case '*':
-		s.addToken(token.STAR, nil)
+		if s.match('*') {
+			_token = token.STAR_STAR
+		} else if s.match('=') {
+			_token = token.STAR_EQUAL
+		} else {
+			_token = token.STAR
+		}
+		s.addToken(_token, nil)
+	case '%':
+		if s.match('=') {
+			_token = token.PERCENT_EQUAL
+		} else {
+			_token = token.PERCENT
+		}
+		s.addToken(_token, nil)
+	case '?':
+		s.addToken(token.QUESTION, nil)
+	case '&':
+		s.addToken(token.AMPERSAND, nil)
+	case '|':
+		s.addToken(token.PIPE, nil)
+	case '^':
+		s.addToken(token.CARET, nil)
+	case '~':
+		s.addToken(token.TILDE, nil)
 	case '!':
 		if s.match('=') {
 			_token = token.BANG_EQUAL
@@ -74,6 +151,8 @@ func (s *_Scanner) scanToken() {
 	case '=':
 		if s.match('=') {
 			_token = token.EQUAL_EQUAL
+		} else if s.match('>') {
+			_token = token.ARROW
 		} else {
 			_token = token.EQUAL
 		}
@@ -81,6 +160,8 @@ func (s *_Scanner) scanToken() {
 	case '<':
 		if s.match('=') {
 			_token = token.LESS_EQUAL
+		} else if s.match('<') {
+			_token = token.LESS_LESS
 		} else {
 			_token = token.LESS
 		}
@@ -88,6 +169,8 @@ func (s *_Scanner) scanToken() {
 	case '>':
 		if s.match('=') {
 			_token = token.GREATER_EQUAL
+		} else if s.match('>') {
+			_token = token.GREATER_GREATER
 		} else {
 			_token = token.GREATER
 		}
@@ -97,6 +180,8 @@ func (s *_Scanner) scanToken() {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+		} else if s.match('=') {
+			s.addToken(token.SLASH_EQUAL, nil)
 		} else {
 			s.addToken(token.SLASH, nil)
 		}
@@ -124,7 +209,7 @@ func (s *_Scanner) identifier() {
 		s.advance()
 	}
 
-	text := s.source[s.start:s.current]
+	text := string(s.source[s.start:s.current])
 	tokenType, exists := s.keywords[text]
 	if !exists {
 		tokenType = token.IDENTIFIER
@@ -134,32 +219,172 @@ func (s *_Scanner) identifier() {
 }
 
 func (s *_Scanner) number() {
-	for s.isDigit(s.peek()) {
-		s.advance()
+	if s.source[s.start] == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			s.radixNumber(16, "hexadecimal")
+			return
+		case 'b', 'B':
+			s.radixNumber(2, "binary")
+			return
+		case 'o', 'O':
+			s.radixNumber(8, "octal")
+			return
+		}
 	}
 
+	s.digits(s.isDigit)
+	isFloat := false
+
 	// look for a fractional part
 	if s.peek() == '.' && s.isDigit(s.peekNext()) {
 		// consume the '.'
 		s.advance()
+		s.digits(s.isDigit)
+		isFloat = true
+	}
 
-		for s.isDigit(s.peek()) {
+	// look for an exponent
+	if s.peek() == 'e' || s.peek() == 'E' {
+		next := s.peekNext()
+		if s.isDigit(next) || ((next == '+' || next == '-') && s.current+2 < len(s.source) && s.isDigit(s.source[s.current+2])) {
+			// consume the 'e' and any sign
 			s.advance()
+			if s.peek() == '+' || s.peek() == '-' {
+				s.advance()
+			}
+
+			s.digits(s.isDigit)
+			isFloat = true
+		}
+	}
+
+	text := strings.ReplaceAll(string(s.source[s.start:s.current]), "_", "")
+	switch s.suffix() {
+	case 'n':
+		if isFloat {
+			s.invalidNumber("BigInt literal '" + text + "' can't have a fraction or exponent.")
+			return
+		}
+
+		s.bigInteger(text, 10)
+		return
+	case 'd':
+		val, ok := decimal.Parse(text)
+		if !ok {
+			s.invalidNumber("Invalid decimal literal '" + text + "'.")
+			return
 		}
+
+		s.addToken(token.NUMBER, val)
+		return
+	}
+
+	if isFloat {
+		val, _ := strconv.ParseFloat(text, 64)
+		s.addToken(token.NUMBER, val)
+		return
+	}
+
+	val, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		// too large for an int64, so the literal becomes a BigInt
+		s.bigInteger(text, 10)
+		return
 	}
 
-	substr := s.source[s.start:s.current]
-	val, _ := strconv.ParseFloat(substr, 64)
 	s.addToken(token.NUMBER, val)
 }
 
+// suffix consumes an 'n' (BigInt) or 'd' (Decimal) marker directly after a
+// number, returning it, or 0 if there isn't one.
+func (s *_Scanner) suffix() rune {
+	c := s.peek()
+	if (c != 'n' && c != 'd') || s.isAlphaNumeric(s.peekNext()) {
+		return 0
+	}
+
+	return s.advance()
+}
+
+// invalidNumber reports a malformed number literal and stands a zero in for
+// it, so that the parser doesn't report the missing number again.
+func (s *_Scanner) invalidNumber(message string) {
+	s.reporter.Error(s.line, message)
+	s.addToken(token.NUMBER, int64(0))
+}
+
+func (s *_Scanner) bigInteger(text string, base int) {
+	val, _ := new(big.Int).SetString(text, base)
+	s.addToken(token.NUMBER, val)
+}
+
+// radixNumber scans the digits of an integer literal following a 0x, 0b or
+// 0o prefix.
+func (s *_Scanner) radixNumber(base int, name string) {
+	// consume the base marker
+	s.advance()
+
+	isRadixDigit := func(c rune) bool {
+		digit := strings.IndexRune("0123456789abcdef", unicode.ToLower(c))
+		return digit >= 0 && digit < base
+	}
+
+	digitsStart := s.current
+	s.digits(isRadixDigit)
+
+	text := strings.ReplaceAll(string(s.source[digitsStart:s.current]), "_", "")
+	isBig := s.peek() == 'n' && !s.isAlphaNumeric(s.peekNext())
+	if isBig {
+		s.advance()
+	}
+
+	if text == "" || s.isAlphaNumeric(s.peek()) {
+		s.invalidNumber("Invalid " + name + " literal.")
+		return
+	}
+
+	val, err := strconv.ParseInt(text, base, 64)
+	if isBig || err != nil {
+		s.bigInteger(text, base)
+		return
+	}
+
+	s.addToken(token.NUMBER, val)
+}
+
+// digits consumes a run of digits, allowing single underscores between them
+// as separators.
+func (s *_Scanner) digits(isDigit func(rune) bool) {
+	for isDigit(s.peek()) || (s.peek() == '_' && isDigit(s.peekNext())) {
+		s.advance()
+	}
+}
+
 func (s *_Scanner) string() {
+	var value strings.Builder
+
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
+		c := s.advance()
+		if c == '\n' {
 			s.line++
 		}
 
-		s.advance()
+		if c == '$' && s.peek() == '{' {
+			s.advance()
+			s.interpolations = append(s.interpolations, 0)
+			s.addToken(token.INTERPOLATION, value.String())
+			return
+		}
+
+		if c == '\\' && !s.isAtEnd() {
+			c = s.escape()
+			if c < 0 {
+				continue
+			}
+		}
+
+		value.WriteRune(c)
 	}
 
 	if s.isAtEnd() {
@@ -169,11 +394,73 @@ func (s *_Scanner) string() {
 
 	s.advance()
 
-	value := s.source[s.start+1 : s.current-1]
-	s.addToken(token.STRING, value)
+	s.addToken(token.STRING, value.String())
+}
+
+// escape consumes the character after a backslash and returns the rune it
+// stands for, or -1 if the sequence was invalid and has been reported.
+func (s *_Scanner) escape() rune {
+	c := s.advance()
+
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	case '"':
+		return '"'
+	case '$':
+		return '$'
+	case '\\':
+		return '\\'
+	case 'u':
+		return s.unicodeEscape()
+	}
+
+	if c == '\n' {
+		s.line++
+	}
+
+	s.reporter.Error(s.line, "Invalid escape sequence '\\"+string(c)+"'.")
+	return -1
+}
+
+func (s *_Scanner) unicodeEscape() rune {
+	if !s.match('{') {
+		s.reporter.Error(s.line, "Expect '{' after '\\u'.")
+		return -1
+	}
+
+	start := s.current
+	for s.peek() != '}' && s.peek() != '"' && !s.isAtEnd() {
+		s.advance()
+	}
+
+	digits := string(s.source[start:s.current])
+	if !s.match('}') {
+		s.reporter.Error(s.line, "Unterminated unicode escape sequence.")
+		return -1
+	}
+
+	code, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) == 0 || len(digits) > 6 {
+		s.reporter.Error(s.line, "Invalid unicode escape sequence '\\u{"+digits+"}'.")
+		return -1
+	}
+
+	if code > unicode.MaxRune || (code >= 0xD800 && code <= 0xDFFF) {
+		s.reporter.Error(s.line, "Unicode escape '\\u{"+digits+"}' is not a valid code point.")
+		return -1
+	}
+
+	return rune(code)
 }
 
-func (s *_Scanner) match(expected byte) bool {
+func (s *_Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
@@ -186,7 +473,7 @@ func (s *_Scanner) match(expected byte) bool {
 	return true
 }
 
-func (s *_Scanner) peek() byte {
+func (s *_Scanner) peek() rune {
 	if s.isAtEnd() {
 		return 0
 	}
@@ -194,7 +481,7 @@ func (s *_Scanner) peek() byte {
 	return s.source[s.current]
 }
 
-func (s *_Scanner) peekNext() byte {
+func (s *_Scanner) peekNext() rune {
 	if s.current+1 >= len(s.source) {
 		return 0
 	}
@@ -202,27 +489,28 @@ func (s *_Scanner) peekNext() byte {
 	return s.source[s.current+1]
 }
 
-func (s *_Scanner) isAlpha(c byte) bool {
+func (s *_Scanner) isAlpha(c rune) bool {
 	return (c >= 'a' && c <= 'z') ||
 		(c >= 'A' && c <= 'Z') ||
-		c == '_'
+		c == '_' ||
+		(c > unicode.MaxASCII && unicode.IsLetter(c))
 }
 
-func (s *_Scanner) isAlphaNumeric(c byte) bool {
+func (s *_Scanner) isAlphaNumeric(c rune) bool {
 	return s.isAlpha(c) || s.isDigit(c)
 }
 
-func (s *_Scanner) isDigit(c byte) bool {
+func (s *_Scanner) isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func (s *_Scanner) advance() byte {
+func (s *_Scanner) advance() rune {
 	s.current++
 	return s.source[s.current-1]
 }
 
 func (s *_Scanner) addToken(tokenType token.TTokentype, literal interface{}) {
-	text := s.source[s.start:s.current]
+	text := string(s.source[s.start:s.current])
 	s.tokens = append(s.tokens, token.NewToken(tokenType, text, literal, s.line))
 }
 
@@ -233,19 +521,28 @@ func (s *_Scanner) isAtEnd() bool {
 func (s *_Scanner) InitKeywords() {
 	s.keywords = make(map[string]token.TTokentype)
 	s.keywords["and"] = token.AND
+	s.keywords["break"] = token.BREAK
+	s.keywords["catch"] = token.CATCH
 	s.keywords["class"] = token.CLASS
+	s.keywords["const"] = token.CONST
+	s.keywords["continue"] = token.CONTINUE
 	s.keywords["else"] = token.ELSE
 	s.keywords["false"] = token.FALSE
+	s.keywords["finally"] = token.FINALLY
 	s.keywords["for"] = token.FOR
 	s.keywords["fun"] = token.FUN
 	s.keywords["if"] = token.IF
+	s.keywords["import"] = token.IMPORT
+	s.keywords["let"] = token.LET
 	s.keywords["nil"] = token.NIL
 	s.keywords["or"] = token.OR
 	s.keywords["print"] = token.PRINT
 	s.keywords["return"] = token.RETURN
 	s.keywords["super"] = token.SUPER
 	s.keywords["this"] = token.THIS
+	s.keywords["throw"] = token.THROW
 	s.keywords["true"] = token.TRUE
+	s.keywords["try"] = token.TRY
 	s.keywords["var"] = token.VAR
 	s.keywords["while"] = token.WHILE
 }
@@ -8,6 +8,9 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
+	COLON
 	COMMA
 	DOT
 	MINUS
@@ -15,37 +18,65 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	PERCENT
+	QUESTION
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
 
 	// BANG One or two character tokens.
 	BANG
 	BANG_EQUAL
 	EQUAL
 	EQUAL_EQUAL
+	ARROW
+	ELLIPSIS
 	GREATER
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	LESS_LESS
+	GREATER_GREATER
+	STAR_STAR
+	PLUS_EQUAL
+	PLUS_PLUS
+	MINUS_EQUAL
+	MINUS_MINUS
+	STAR_EQUAL
+	SLASH_EQUAL
+	PERCENT_EQUAL
 
 	// IDENTIFIER Literals.
 	IDENTIFIER
 	STRING
+	INTERPOLATION
 	NUMBER
 
 	// AND Keywords.
 	AND
+	BREAK
+	CATCH
 	CLASS
+	CONST
+	CONTINUE
 	ELSE
 	FALSE
+	FINALLY
 	FUN
 	FOR
 	IF
+	IMPORT
+	LET
 	NIL
 	OR
 	PRINT
 	RETURN
 	SUPER
 	THIS
+	THROW
 	TRUE
+	TRY
 	VAR
 	WHILE
 
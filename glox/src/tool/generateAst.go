@@ -18,22 +18,48 @@ func main() {
 	g := GenerateAst{}
 	outputDir := os.Args[1]
 	exprTypes := []string{
-		"Assign   : token.Token Name, Expr Value",
-		"Binary   : Expr Left, token.Token Operator, Expr Right",
-		"Grouping : Expr Expression",
-		"Literal  : interface{} Value",
-		"Logical  : Expr Left, token.Token Operator, Expr Right",
-		"Unary    : token.Token Operator, Expr Right",
-		"Variable : token.Token Name",
+		"Assign            : token.Token Name, Expr Value",
+		"Binary            : Expr Left, token.Token Operator, Expr Right",
+		"Call              : Expr Callee, token.Token Paren, []Expr Arguments",
+		"CompoundAssign    : Expr Target, token.Token Operator, Expr Value",
+		"DestructureAssign : token.Token Bracket, []Expr Targets, Expr Rest, Expr Value",
+		"Get               : Expr Object, token.Token Name",
+		"Grouping          : Expr Expression",
+		"Increment         : Expr Target, token.Token Operator, bool Prefix",
+		"Index             : Expr Object, token.Token Bracket, Expr Index",
+		"IndexSet          : Expr Object, token.Token Bracket, Expr Index, Expr Value",
+		"Interpolation     : []Expr Parts",
+		"Lambda            : *Function Function",
+		"ListLiteral       : token.Token Bracket, []Expr Elements",
+		"Literal           : interface{} Value",
+		"Logical           : Expr Left, token.Token Operator, Expr Right",
+		"MapLiteral        : token.Token Brace, []Expr Keys, []Expr Values",
+		"Match             : token.Token Keyword, Expr Subject, []*MatchArm Arms",
+		"Set               : Expr Object, token.Token Name, Expr Value",
+		"Super             : token.Token Keyword, token.Token Method",
+		"Ternary           : Expr Condition, Expr ThenBranch, Expr ElseBranch",
+		"This              : token.Token Keyword",
+		"Unary             : token.Token Operator, Expr Right",
+		"Variable          : token.Token Name",
 	}
 
 	stmtTypes := []string{
-		"Statements : []Stmt",
-		"Expression : Expr Expression",
-		"If         : Condition Expr, ThenBranch Stmt, ElseBranch Stmt",
-		"Print      : Expr Expression",
-		"Var        : token.Token Name, Initialiser Expr",
-		"While      : Condition Expr, Body Stmt",
+		"Block       : []Stmt Statements",
+		"Break       : token.Token Keyword",
+		"Class       : token.Token Name, *Variable Superclass, []*Function Methods",
+		"Continue    : token.Token Keyword",
+		"Destructure : token.Token Keyword, Pattern Pattern, Expr Initialiser, bool Constant",
+		"Expression  : Expr Expression",
+		"Function    : token.Token Name, []token.Token Params, []Stmt Body",
+		"If          : Expr Condition, Stmt ThenBranch, Stmt ElseBranch",
+		"Import      : token.Token Keyword, token.Token Path, token.Token Alias, []token.Token Names",
+		"MatchStmt   : token.Token Keyword, Expr Subject, []*MatchArm Arms",
+		"Print       : Expr Expression",
+		"Return      : token.Token Keyword, Expr Value",
+		"Throw       : token.Token Keyword, Expr Value",
+		"Try         : token.Token Keyword, *Block Body, token.Token CatchName, *Block CatchBody, *Block FinallyBody",
+		"Var         : token.Token Name, Expr Initialiser, bool Constant",
+		"While       : token.Token Keyword, Expr Condition, Stmt Body, Expr Increment",
 	}
 
 	g.defineAst(outputDir, "Expr", exprTypes)
@@ -117,12 +143,13 @@ func (g *GenerateAst) defineType(writer *bufio.Writer, baseName, className, fiel
 		os.Exit(68)
 	}
 
-	_, err = writer.WriteString(fmt.Sprintf("func (expr *%s) Accept(visitor Visitor) (interface{}, error) {\n", className))
+	receiver := strings.ToLower(baseName)
+	_, err = writer.WriteString(fmt.Sprintf("func (%s *%s) Accept(visitor Visitor) (interface{}, error) {\n", receiver, className))
 	if err != nil {
 		fmt.Printf("Error writing to file: %s\n", err)
 		os.Exit(68)
 	}
-	_, err = writer.WriteString(fmt.Sprintf("\treturn visitor.Visit%s(expr)\n", className))
+	_, err = writer.WriteString(fmt.Sprintf("\treturn visitor.Visit%s(%s)\n", className, receiver))
 	if err != nil {
 		fmt.Printf("Error writing to file: %s\n", err)
 		os.Exit(68)
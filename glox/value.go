@@ -0,0 +1,457 @@
+package glox
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/dmcg310/glox/src/decimal"
+	"github.com/dmcg310/glox/src/lox"
+)
+
+type Kind int
+
+const (
+	Nil Kind = iota
+	Bool
+	Int
+	BigInt
+	Decimal
+	Float
+	String
+	List
+	Map
+	Function
+	Class
+	Instance
+	Module
+	Host
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Nil:
+		return "nil"
+	case Bool:
+		return "bool"
+	case Int:
+		return "int"
+	case BigInt:
+		return "BigInt"
+	case Decimal:
+		return "Decimal"
+	case Float:
+		return "float"
+	case String:
+		return "string"
+	case List:
+		return "list"
+	case Map:
+		return "map"
+	case Function:
+		return "function"
+	case Class:
+		return "class"
+	case Instance:
+		return "instance"
+	case Module:
+		return "module"
+	case Host:
+		return "host object"
+	}
+
+	return "unknown"
+}
+
+// Value is a Lox value passed between Go and a VM. The zero Value is nil.
+type Value struct {
+	raw interface{}
+}
+
+// ValueOf converts a Go value to a Lox one. Booleans, integers, floats,
+// strings, *big.Int and *big.Rat (as a Decimal) convert directly, and
+// slices and maps convert element by element into new lists and maps.
+// Structs and pointers are wrapped with NewHost, exposing everything
+// exported. Values are passed through unchanged.
+func ValueOf(value interface{}) (Value, error) {
+	return valueOf(value, HostOptions{})
+}
+
+// valueOf is ValueOf with opts for the host objects it makes, so that
+// values reached through a host object are limited the same way it is.
+func valueOf(value interface{}, opts HostOptions) (Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return Value{}, nil
+	case Value:
+		return v, nil
+	case bool, int64, float64, string:
+		return Value{raw: v}, nil
+	case int:
+		return Value{raw: int64(v)}, nil
+	case int8:
+		return Value{raw: int64(v)}, nil
+	case int16:
+		return Value{raw: int64(v)}, nil
+	case int32:
+		return Value{raw: int64(v)}, nil
+	case uint8:
+		return Value{raw: int64(v)}, nil
+	case uint16:
+		return Value{raw: int64(v)}, nil
+	case uint32:
+		return Value{raw: int64(v)}, nil
+	case uint:
+		return unsigned(uint64(v)), nil
+	case uint64:
+		return unsigned(v), nil
+	case float32:
+		return Value{raw: float64(v)}, nil
+	case *big.Int:
+		return Value{raw: new(big.Int).Set(v)}, nil
+	case *big.Rat:
+		return Value{raw: decimal.FromRat(v)}, nil
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array:
+		elements := make([]interface{}, reflected.Len())
+		for idx := range elements {
+			element, err := valueOf(reflected.Index(idx).Interface(), opts)
+			if err != nil {
+				return Value{}, err
+			}
+
+			elements[idx] = element.raw
+		}
+
+		return Value{raw: lox.NewLoxList(elements)}, nil
+	case reflect.Map:
+		return mapOf(reflected, opts)
+	case reflect.Pointer, reflect.Struct:
+		if reflected.Kind() == reflect.Pointer && reflected.IsNil() {
+			return Value{}, nil
+		}
+
+		return NewHost(value, opts), nil
+	}
+
+	return Value{}, fmt.Errorf("glox: can't convert %T to a Lox value", value)
+}
+
+func unsigned(value uint64) Value {
+	if value > math.MaxInt64 {
+		return Value{raw: new(big.Int).SetUint64(value)}
+	}
+
+	return Value{raw: int64(value)}
+}
+
+// mapOf converts a Go map. Go doesn't order its maps, so the entries are
+// added in the order of their printed keys to keep the result
+// deterministic.
+func mapOf(reflected reflect.Value, opts HostOptions) (Value, error) {
+	keys := make([]Value, 0, reflected.Len())
+	values := make(map[int]Value, reflected.Len())
+
+	iter := reflected.MapRange()
+	for iter.Next() {
+		key, err := valueOf(iter.Key().Interface(), opts)
+		if err != nil {
+			return Value{}, err
+		}
+
+		value, err := valueOf(iter.Value().Interface(), opts)
+		if err != nil {
+			return Value{}, err
+		}
+
+		values[len(keys)] = value
+		keys = append(keys, key)
+	}
+
+	order := make([]int, len(keys))
+	for idx := range order {
+		order[idx] = idx
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return keys[order[a]].String() < keys[order[b]].String()
+	})
+
+	m := lox.NewLoxMap()
+	for _, idx := range order {
+		m.Set(keys[idx].raw, values[idx].raw)
+	}
+
+	return Value{raw: m}, nil
+}
+
+func (v Value) Kind() Kind {
+	switch v.raw.(type) {
+	case nil:
+		return Nil
+	case bool:
+		return Bool
+	case int64:
+		return Int
+	case *big.Int:
+		return BigInt
+	case *decimal.Decimal:
+		return Decimal
+	case float64:
+		return Float
+	case string:
+		return String
+	case *lox.LoxList:
+		return List
+	case *lox.LoxMap:
+		return Map
+	case *lox.LoxClass:
+		return Class
+	case *lox.LoxInstance:
+		return Instance
+	case *lox.LoxModule:
+		return Module
+	case *hostObject:
+		return Host
+	case lox.LoxCallable:
+		return Function
+	}
+
+	return Nil
+}
+
+func (v Value) IsNil() bool {
+	return v.raw == nil
+}
+
+// Truthy reports whether Lox treats the value as true: everything but nil
+// and false is.
+func (v Value) Truthy() bool {
+	if b, ok := v.raw.(bool); ok {
+		return b
+	}
+
+	return v.raw != nil
+}
+
+// String formats the value the way print shows it.
+func (v Value) String() string {
+	return lox.Stringify(v.raw)
+}
+
+func (v Value) AsBool() (bool, error) {
+	b, ok := v.raw.(bool)
+	if !ok {
+		return false, v.mismatch("a bool")
+	}
+
+	return b, nil
+}
+
+func (v Value) AsString() (string, error) {
+	s, ok := v.raw.(string)
+	if !ok {
+		return "", v.mismatch("a string")
+	}
+
+	return s, nil
+}
+
+// AsInt accepts any number with a whole value that fits an int64.
+func (v Value) AsInt() (int64, error) {
+	switch num := v.raw.(type) {
+	case int64:
+		return num, nil
+	case *big.Int:
+		if num.IsInt64() {
+			return num.Int64(), nil
+		}
+	case *decimal.Decimal:
+		if integer := num.Int(); num.IsInt() && integer.IsInt64() {
+			return integer.Int64(), nil
+		}
+	case float64:
+		if num == math.Trunc(num) && num >= math.MinInt64 && num < math.MaxInt64 {
+			return int64(num), nil
+		}
+	}
+
+	return 0, v.mismatch("an integer")
+}
+
+// AsBigInt accepts any number with a whole value.
+func (v Value) AsBigInt() (*big.Int, error) {
+	switch num := v.raw.(type) {
+	case int64:
+		return big.NewInt(num), nil
+	case *big.Int:
+		return new(big.Int).Set(num), nil
+	case *decimal.Decimal:
+		if num.IsInt() {
+			return num.Int(), nil
+		}
+	case float64:
+		if !math.IsInf(num, 0) && num == math.Trunc(num) {
+			integer, _ := big.NewFloat(num).Int(nil)
+			return integer, nil
+		}
+	}
+
+	return nil, v.mismatch("an integer")
+}
+
+// AsRat returns the exact value of an integer, BigInt or Decimal, or of a
+// float, which is always a binary fraction.
+func (v Value) AsRat() (*big.Rat, error) {
+	switch num := v.raw.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(num), nil
+	case *big.Int:
+		return new(big.Rat).SetInt(num), nil
+	case *decimal.Decimal:
+		return num.Rat(), nil
+	case float64:
+		if !math.IsInf(num, 0) && !math.IsNaN(num) {
+			return new(big.Rat).SetFloat64(num), nil
+		}
+	}
+
+	return nil, v.mismatch("a finite number")
+}
+
+// AsFloat accepts any number, rounding exact ones to the nearest float.
+func (v Value) AsFloat() (float64, error) {
+	switch num := v.raw.(type) {
+	case int64:
+		return float64(num), nil
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(num).Float64()
+		return f, nil
+	case *decimal.Decimal:
+		return num.Float64(), nil
+	case float64:
+		return num, nil
+	}
+
+	return 0, v.mismatch("a number")
+}
+
+// AsList returns the elements of a list. The slice is a copy, but the
+// elements are shared with the script.
+func (v Value) AsList() ([]Value, error) {
+	list, ok := v.raw.(*lox.LoxList)
+	if !ok {
+		return nil, v.mismatch("a list")
+	}
+
+	elements := make([]Value, len(list.Elements))
+	for idx, element := range list.Elements {
+		elements[idx] = Value{raw: element}
+	}
+
+	return elements, nil
+}
+
+// AsMap returns the entries of a map whose keys are all strings.
+func (v Value) AsMap() (map[string]Value, error) {
+	m, ok := v.raw.(*lox.LoxMap)
+	if !ok {
+		return nil, v.mismatch("a map")
+	}
+
+	entries := make(map[string]Value, m.Len())
+	values := m.Values()
+	for idx, key := range m.Keys() {
+		str, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("glox: map key %s is not a string", lox.Stringify(key))
+		}
+
+		entries[str] = Value{raw: values[idx]}
+	}
+
+	return entries, nil
+}
+
+// Interface converts the value to plain Go: nil, bool, int64, *big.Int,
+// *big.Rat for a Decimal, float64, string, []interface{} for a list and
+// map[interface{}]interface{} for a map. A map key that can't be a Go map
+// key, such as a list, stays a Value. A list or map that contains itself
+// converts to a slice or map that contains itself too. A host object gives
+// back the Go value it wraps. Functions, classes, instances and modules
+// have no Go equivalent and are returned as they are held.
+func (v Value) Interface() interface{} {
+	return v.toGo(make(map[interface{}]interface{}))
+}
+
+// toGo is Interface, with seen holding the lists and maps already
+// converted so that cycles are converted once.
+func (v Value) toGo(seen map[interface{}]interface{}) interface{} {
+	switch value := v.raw.(type) {
+	case *big.Int:
+		return new(big.Int).Set(value)
+	case *decimal.Decimal:
+		return value.Rat()
+	case *lox.LoxList:
+		if converted, ok := seen[value]; ok {
+			return converted
+		}
+
+		elements := make([]interface{}, len(value.Elements))
+		seen[value] = elements
+		for idx, element := range value.Elements {
+			elements[idx] = Value{raw: element}.toGo(seen)
+		}
+
+		return elements
+	case *lox.LoxMap:
+		if converted, ok := seen[value]; ok {
+			return converted
+		}
+
+		entries := make(map[interface{}]interface{}, value.Len())
+		seen[value] = entries
+		values := value.Values()
+		for idx, key := range value.Keys() {
+			entries[Value{raw: key}.toGoKey(seen)] = Value{raw: values[idx]}.toGo(seen)
+		}
+
+		return entries
+	case *hostObject:
+		return value.Interface()
+	}
+
+	return v.raw
+}
+
+func (v Value) toGoKey(seen map[interface{}]interface{}) interface{} {
+	switch v.raw.(type) {
+	case *lox.LoxList, *lox.LoxMap:
+		return v
+	}
+
+	key := v.toGo(seen)
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		return v
+	}
+
+	return key
+}
+
+func (v Value) mismatch(expected string) error {
+	return &mismatchError{expected: expected, got: v.Kind()}
+}
+
+type mismatchError struct {
+	expected string
+	got      Kind
+}
+
+func (e *mismatchError) Error() string {
+	return fmt.Sprintf("glox: expected %s but got %s", e.expected, e.got)
+}